@@ -3,12 +3,20 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"text/tabwriter"
+	// Embed the zone database so minimal images without tzdata still resolve
+	// Europe/London.
+	_ "time/tzdata"
 
 	"github.com/Takenobou/redbridge-council-rubbish-scraper/internal/calendar"
 	"github.com/Takenobou/redbridge-council-rubbish-scraper/internal/config"
@@ -17,6 +25,9 @@ import (
 )
 
 func main() {
+	once := flag.Bool("once", false, "scrape once, print the collections and exit without starting the server")
+	flag.Parse()
+
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
@@ -25,42 +36,139 @@ func main() {
 		log.Fatalf("config: %v", err)
 	}
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
+	opts := &slog.HandlerOptions{Level: cfg.LogLevel}
+	var handler slog.Handler = slog.NewJSONHandler(os.Stdout, opts)
+	if cfg.LogFormat == "text" {
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	}
+	logger := slog.New(handler)
 
-	scraperClient, err := scraper.New(scraper.Config{
-		BaseURL:        cfg.BaseURL,
-		SchedulePath:   cfg.SchedulePath,
-		UPRN:           cfg.UPRN,
-		AddressLine:    cfg.AddressLine,
-		Postcode:       cfg.Postcode,
-		Latitude:       cfg.Latitude,
-		Longitude:      cfg.Longitude,
-		UserAgent:      cfg.UserAgent,
-		StartHour:      cfg.StartHour,
-		RequestTimeout: cfg.RequestTimeout,
-		Timezone:       cfg.Timezone,
-	})
-	if err != nil {
-		logger.Error("scraper init failed", slog.String("error", err.Error()))
-		os.Exit(1)
+	scrapers := make([]*scraper.Scraper, 0, len(cfg.UPRNs))
+	for _, uprn := range cfg.UPRNs {
+		scraperClient, err := newScraper(cfg, uprn, logger)
+		if err != nil {
+			logger.Error("scraper init failed", slog.String("uprn", uprn), slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		scrapers = append(scrapers, scraperClient)
+	}
+
+	if *once || cfg.ScrapeOnce {
+		if err := printCollections(ctx, os.Stdout, cfg.UPRNs, scrapers); err != nil {
+			fmt.Fprintf(os.Stderr, "scrape failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
 	}
 
 	calendarBuilder, err := calendar.NewBuilder(calendar.Config{
-		Name:        cfg.CalendarName,
-		Description: cfg.CalendarDesc,
-		Timezone:    cfg.Timezone,
+		Name:             cfg.CalendarName,
+		Description:      cfg.CalendarDesc,
+		Timezone:         cfg.Timezone,
+		AllDay:           cfg.AllDayEvents,
+		EventDuration:    cfg.EventDuration,
+		WindowEndHour:    cfg.WindowEndHour,
+		Types:            cfg.CalendarTypes,
+		MaxEvents:        cfg.MaxEvents,
+		Location:         eventLocation(cfg),
+		SummaryTemplate:  cfg.SummaryTemplate,
+		TypeTranslations: cfg.TypeTranslations,
+		EventDescription: cfg.EventDescription,
+		UseRecurrence:    cfg.UseRecurrence,
+		GroupByDay:       cfg.GroupByDay,
+		Transparent:      cfg.Transparent,
+		EventStatus:      cfg.EventStatus,
+		Alarms:           cfg.Alarms,
+		AlarmAction:      cfg.AlarmAction,
+		AlarmEmail:       cfg.AlarmEmail,
+		CategoryColors:   cfg.CategoryColors,
+		Categories:       cfg.Categories,
 	})
 	if err != nil {
 		logger.Error("calendar init failed", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
 
-	srv := server.New(cfg, scraperClient, calendarBuilder, logger)
+	srv, err := server.New(cfg, scrapers[0], calendarBuilder, logger)
+	if err != nil {
+		logger.Error("server init failed", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	for i, uprn := range cfg.UPRNs[1:] {
+		srv.AddAddress(uprn, scrapers[i+1])
+	}
 
 	if err := srv.Run(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		logger.Error("server exited with error", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
 }
+
+// printCollections scrapes every address once and writes the results to w as
+// a table, for checking a new UPRN without running the server.
+func printCollections(ctx context.Context, w io.Writer, uprns []string, scrapers []*scraper.Scraper) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "UPRN\tDATE\tTYPE\tNOTE")
+	for i, scr := range scrapers {
+		collections, err := scr.FetchCollections(ctx)
+		if err != nil {
+			return fmt.Errorf("uprn %s: %w", uprns[i], err)
+		}
+		for _, c := range collections {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", uprns[i], c.Date.Format("Mon 2006-01-02 15:04"), c.Type, c.Note)
+		}
+	}
+	return tw.Flush()
+}
+
+// newScraper builds a scraper for uprn. The optional address details only
+// describe the primary UPRN, so they are omitted for additional addresses.
+func newScraper(cfg config.Config, uprn string, logger *slog.Logger) (*scraper.Scraper, error) {
+	scfg := scraper.Config{
+		BaseURL:             cfg.BaseURL,
+		SchedulePath:        cfg.SchedulePath,
+		UPRN:                uprn,
+		UserAgent:           cfg.UserAgent,
+		UserAgents:          cfg.UserAgents,
+		ProxyURL:            cfg.ProxyURL,
+		OriginUsername:      cfg.OriginUsername,
+		OriginPassword:      cfg.OriginPassword,
+		SaveAddressMethod:   cfg.SaveAddressMethod,
+		StartHour:           cfg.StartHour,
+		StartHourByType:     cfg.StartHourByType,
+		TypeAliases:         cfg.TypeAliases,
+		RequestTimeout:      cfg.RequestTimeout,
+		MaxRetries:          cfg.MaxRetries,
+		RetryBaseDelay:      cfg.RetryBaseDelay,
+		MaxBodyBytes:        cfg.MaxBodyBytes,
+		MaxIdleConns:        cfg.MaxIdleConns,
+		MaxIdleConnsPerHost: cfg.MaxIdlePerHost,
+		IdleConnTimeout:     cfg.IdleConnTimeout,
+		RequestDelay:        cfg.RequestDelay,
+		Timezone:            cfg.Timezone,
+		Logger:              logger.With(slog.String("uprn", uprn)),
+	}
+	if uprn == cfg.UPRN {
+		scfg.AddressLine = cfg.AddressLine
+		scfg.Postcode = cfg.Postcode
+		scfg.Latitude = cfg.Latitude
+		scfg.Longitude = cfg.Longitude
+	}
+	return scraper.New(scfg)
+}
+
+// eventLocation is the primary property's address for event LOCATION, or ""
+// when EVENT_LOCATION is off or neither ADDRESS_LINE nor POSTCODE is set.
+func eventLocation(cfg config.Config) string {
+	if !cfg.EventLocation {
+		return ""
+	}
+	location := strings.TrimSpace(cfg.AddressLine)
+	if cfg.Postcode == "" || strings.Contains(strings.ToUpper(location), cfg.Postcode) {
+		return location
+	}
+	if location == "" {
+		return cfg.Postcode
+	}
+	return location + ", " + cfg.Postcode
+}
@@ -6,6 +6,8 @@ require (
 	github.com/PuerkitoBio/goquery v1.10.2
 	github.com/arran4/golang-ical v0.3.2
 	github.com/prometheus/client_golang v1.20.4
+	golang.org/x/sync v0.17.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -2,8 +2,12 @@ package calendar
 
 import (
 	"fmt"
+	"io"
+	"net/mail"
 	"regexp"
+	"sort"
 	"strings"
+	"text/template"
 	"time"
 	"unicode/utf8"
 
@@ -13,23 +17,129 @@ import (
 )
 
 const (
-	productID          = "-//redbridge-ics//EN"
-	defaultInstruction = "Place bins out by 06:00 on collection day."
+	productID            = "-//redbridge-ics//EN"
+	defaultInstruction   = "Place bins out by {{.Time}} on collection day."
+	defaultEventDuration = time.Hour
+	localTimestampFormat = "20060102T150405"
 )
 
-var slugRegex = regexp.MustCompile(`[^a-z0-9]+`)
+var (
+	slugRegex    = regexp.MustCompile(`[^a-z0-9]+`)
+	triggerRegex = regexp.MustCompile(`^[+-]?P(\d+W|\d+D(T(\d+H)?(\d+M)?(\d+S)?)?|T(\d+H)?(\d+M)?(\d+S)?)$`)
+)
+
+var defaultAlarms = []string{"-PT11H", "-PT30M"}
+
+// tzTransition is one STANDARD or DAYLIGHT rule of a VTIMEZONE.
+type tzTransition struct {
+	daylight   bool
+	name       string
+	offsetFrom string
+	offsetTo   string
+	start      string
+	rule       string
+}
+
+// vtimezones holds VTIMEZONE definitions for the zones the feed is used with.
+// Events in any other zone keep UTC timestamps, which every client accepts,
+// unless they recur: those get rules derived by derivedTimezone.
+var vtimezones = map[string][]tzTransition{
+	"Europe/London": {
+		{daylight: true, name: "BST", offsetFrom: "+0000", offsetTo: "+0100", start: "19700329T010000", rule: "FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU"},
+		{name: "GMT", offsetFrom: "+0100", offsetTo: "+0000", start: "19701025T020000", rule: "FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU"},
+	},
+}
 
 // Config defines calendar level metadata.
 type Config struct {
 	Name        string
 	Description string
 	Timezone    string
+	Alarms      []string
+	// AlarmAction is the VALARM ACTION: "display" (the default), "audio" for
+	// an audible alert, or "email", which mails AlarmEmail instead.
+	AlarmAction string
+	AlarmEmail  string
+	// AllDay emits date-only events; EventDuration is ignored when set.
+	AllDay        bool
+	EventDuration time.Duration
+	// WindowEndHour, when set, ends each timed event at this local hour on
+	// its day, for councils that give a collection window such as
+	// 06:00-16:00. Events starting at or after it keep EventDuration.
+	WindowEndHour int
+	// Types limits the feed to these waste types (case-insensitive); empty means all.
+	Types []string
+	// SummaryTemplate is a text/template rendered with {{.Type}} for each
+	// event summary; TypeTranslations maps scraped type names to display names.
+	SummaryTemplate  string
+	TypeTranslations map[string]string
+	// EventDescription is a text/template for the instruction shown when the
+	// council lists none, rendered with {{.Type}} and {{.Time}} (HH:MM local).
+	EventDescription string
+	// CategoryColors sets an RFC 7986 COLOR per waste type, and Categories
+	// overrides the CATEGORIES value per type. Both match types case-insensitively.
+	CategoryColors map[string]string
+	Categories     map[string]string
+	// GroupByDay emits one event per day listing every type collected that
+	// day, instead of one event per type. It takes precedence over UseRecurrence.
+	GroupByDay bool
+	// UseRecurrence collapses each weekly or fortnightly type into a single
+	// RRULE event. Types whose dates or notes break the cadence stay discrete.
+	UseRecurrence bool
+	// Transparent marks events TRANSP:TRANSPARENT so they don't show as busy
+	// in free/busy lookups.
+	Transparent bool
+	// DtStamp fixes the DTSTAMP of every component. When zero each event is
+	// stamped with its own start, so rebuilding unchanged data yields the same
+	// bytes (and ETag) rather than tracking the wall clock.
+	DtStamp time.Time
+	// Location is set as each event's LOCATION, typically the property
+	// address, so feeds for different properties can be told apart.
+	Location string
+	// MaxEvents caps Events and Build at the earliest N events, counted
+	// after Types filtering and grouping; zero means no limit.
+	MaxEvents int
+	// EventStatus sets STATUS on every event: CANCELLED when all of its
+	// collections are ones the council marked as skipped, CONFIRMED otherwise,
+	// so clients can show skipped weeks struck through.
+	EventStatus bool
+	// UPRN, when set, is added to every event UID (refuse-20251202-<uprn>@...)
+	// so events from feeds for different properties don't deduplicate
+	// against each other when subscribed in one calendar client.
+	UPRN string
 }
 
 // Builder transforms scraped data into an .ics payload.
 type Builder struct {
-	cfg      Config
-	location *time.Location
+	cfg         Config
+	location    *time.Location
+	summary     *template.Template
+	instruction *template.Template
+}
+
+// Event is one calendar entry as Build emits it, for clients that want the
+// feed's data without parsing iCalendar.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	Categories  []string
+	Color       string
+	// Rule is the RRULE value for recurring events, empty otherwise.
+	Rule   string
+	Alarms []string
+	// Status is the STATUS value, empty unless Config.EventStatus is set.
+	Status string
+}
+
+// eventData is passed to the summary and description templates.
+type eventData struct {
+	Type string
+	Time string
 }
 
 // NewBuilder initialises a calendar builder with timezone handling.
@@ -41,17 +151,83 @@ func NewBuilder(cfg Config) (*Builder, error) {
 		cfg.Timezone = "Europe/London"
 	}
 
+	if cfg.EventDuration <= 0 {
+		cfg.EventDuration = defaultEventDuration
+	}
+	if cfg.WindowEndHour < 0 || cfg.WindowEndHour > 24 {
+		return nil, fmt.Errorf("collection window end %d must be an hour between 0 and 24", cfg.WindowEndHour)
+	}
+	if len(cfg.Alarms) == 0 {
+		cfg.Alarms = defaultAlarms
+	}
+	for _, trigger := range cfg.Alarms {
+		if !validTrigger(trigger) {
+			return nil, fmt.Errorf("invalid alarm trigger %q", trigger)
+		}
+	}
+	switch action := ics.Action(strings.ToUpper(strings.TrimSpace(cfg.AlarmAction))); action {
+	case "":
+		cfg.AlarmAction = string(ics.ActionDisplay)
+	case ics.ActionDisplay, ics.ActionAudio:
+		cfg.AlarmAction = string(action)
+	case ics.ActionEmail:
+		// RFC 5545 requires an ATTENDEE on EMAIL alarms.
+		if cfg.AlarmEmail == "" {
+			return nil, fmt.Errorf("alarm action EMAIL needs an email address")
+		}
+		addr, err := mail.ParseAddress(cfg.AlarmEmail)
+		if err != nil {
+			return nil, fmt.Errorf("invalid alarm email %q: %w", cfg.AlarmEmail, err)
+		}
+		cfg.AlarmAction = string(action)
+		cfg.AlarmEmail = addr.Address
+	default:
+		return nil, fmt.Errorf("invalid alarm action %q: want display, audio or email", cfg.AlarmAction)
+	}
+
+	var summary *template.Template
+	if cfg.SummaryTemplate != "" {
+		tmpl, err := parseTemplate("summary", cfg.SummaryTemplate)
+		if err != nil {
+			return nil, err
+		}
+		summary = tmpl
+	}
+
+	if cfg.EventDescription == "" {
+		cfg.EventDescription = defaultInstruction
+	}
+	instruction, err := parseTemplate("description", cfg.EventDescription)
+	if err != nil {
+		return nil, err
+	}
+
 	loc, err := time.LoadLocation(cfg.Timezone)
 	if err != nil {
 		return nil, fmt.Errorf("load timezone: %w", err)
 	}
 
 	return &Builder{
-		cfg:      cfg,
-		location: loc,
+		cfg:         cfg,
+		location:    loc,
+		summary:     summary,
+		instruction: instruction,
 	}, nil
 }
 
+// parseTemplate parses text and renders it once with sample data so that
+// unknown fields are reported at startup rather than on every Build.
+func parseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s template: %w", name, err)
+	}
+	if err := tmpl.Execute(io.Discard, eventData{Type: "Refuse", Time: "06:00"}); err != nil {
+		return nil, fmt.Errorf("render %s template: %w", name, err)
+	}
+	return tmpl, nil
+}
+
 // Build creates the textual iCalendar representation.
 func (b *Builder) Build(collections []scraper.Collection) ([]byte, error) {
 	cal := ics.NewCalendar()
@@ -64,36 +240,504 @@ func (b *Builder) Build(collections []scraper.Collection) ([]byte, error) {
 		cal.SetXWRCalDesc(b.cfg.Description)
 	}
 
+	events, err := b.Events(collections)
+	if err != nil {
+		return nil, err
+	}
+	if rules := b.vtimezone(events); rules != nil && !b.cfg.AllDay {
+		cal.SetXWRTimezone(b.cfg.Timezone)
+		addTimezone(cal, b.cfg.Timezone, rules)
+	}
+	for _, e := range events {
+		b.addEvent(cal, e)
+	}
+	return []byte(cal.Serialize()), nil
+}
+
+// limit returns the earliest MaxEvents events, in start order.
+func (b *Builder) limit(events []Event) []Event {
+	if b.cfg.MaxEvents <= 0 || len(events) <= b.cfg.MaxEvents {
+		return events
+	}
+	sorted := append([]Event(nil), events...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Start.Before(sorted[j].Start) })
+	return sorted[:b.cfg.MaxEvents]
+}
+
+// Events returns the entries Build would emit for collections, in order.
+func (b *Builder) Events(collections []scraper.Collection) ([]Event, error) {
+	events, err := b.events(collections)
+	if err != nil {
+		return nil, err
+	}
+	return b.limit(events), nil
+}
+
+func (b *Builder) events(collections []scraper.Collection) ([]Event, error) {
+	var events []Event
+	if b.cfg.GroupByDay {
+		for _, group := range b.groupByDay(collections) {
+			e, err := b.newEvent(group, "")
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, e)
+		}
+		return events, nil
+	}
+
+	var series map[string]recurrence
+	if b.cfg.UseRecurrence {
+		series = b.recurringSeries(collections)
+	}
+	emitted := make(map[string]bool, len(series))
+
 	for _, collection := range collections {
-		event := cal.AddEvent(eventID(collection))
-		event.SetSummary(fmt.Sprintf("Bin: %s", titleCase(collection.Type)))
-		event.SetDescription(eventDescription(collection))
-		event.SetProperty(ics.ComponentPropertyCategories, collection.Type)
+		if !b.includes(collection.Type) {
+			continue
+		}
+		group, rule := []scraper.Collection{collection}, ""
+		if rec, ok := series[collection.Type]; ok {
+			if emitted[collection.Type] {
+				continue
+			}
+			emitted[collection.Type] = true
+			group, rule = []scraper.Collection{rec.first}, rec.rule
+		}
+		e, err := b.newEvent(group, rule)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
 
-		start := collection.Date.In(b.location)
-		end := start.Add(time.Hour)
-		event.SetStartAt(start)
-		event.SetEndAt(end)
-		event.SetDtStampTime(time.Now())
+// newEvent builds the entry for a group of collections on the same day,
+// repeating it by rule when set. Groups hold a single collection unless
+// GroupByDay is enabled; the first collection sets the start time.
+func (b *Builder) newEvent(group []scraper.Collection, rule string) (Event, error) {
+	first := group[0]
+	uid := EventID(first)
+	if len(group) > 1 {
+		uid = fmt.Sprintf("bins-%s@redbridge-ics", first.Date.Format("20060102"))
+	}
+	if b.cfg.UPRN != "" {
+		uid = strings.Replace(uid, "@", "-"+b.cfg.UPRN+"@", 1)
+	}
 
-		addAlarm(event, "-PT11H")
-		addAlarm(event, "-PT30M")
+	types := make([]string, 0, len(group))
+	categories := make([]string, 0, len(group))
+	descriptions := make([]string, 0, len(group))
+	color := ""
+	cancelled := true
+	for _, collection := range group {
+		cancelled = cancelled && collection.Skipped()
+		types = append(types, collection.Type)
+		category := collection.Type
+		if override, ok := lookupType(b.cfg.Categories, collection.Type); ok {
+			category = override
+		}
+		categories = append(categories, category)
+		if c, ok := lookupType(b.cfg.CategoryColors, collection.Type); ok && color == "" {
+			color = c
+		}
+
+		description, err := b.eventDescription(collection)
+		if err != nil {
+			return Event{}, err
+		}
+		if len(group) > 1 {
+			description = strings.ToUpper(b.displayName(collection.Type)) + "\n" + description
+		}
+		descriptions = append(descriptions, description)
 	}
 
-	return []byte(cal.Serialize()), nil
+	summary, err := b.Summary(types)
+	if err != nil {
+		return Event{}, err
+	}
+
+	e := Event{
+		UID:         uid,
+		Summary:     summary,
+		Description: strings.Join(descriptions, "\n\n"),
+		Location:    b.cfg.Location,
+		Start:       first.Date.In(b.location),
+		AllDay:      b.cfg.AllDay,
+		Categories:  categories,
+		Color:       color,
+		Rule:        rule,
+		Alarms:      append([]string(nil), b.cfg.Alarms...),
+	}
+	if b.cfg.EventStatus {
+		e.Status = string(ics.ObjectStatusConfirmed)
+		if cancelled {
+			e.Status = string(ics.ObjectStatusCancelled)
+		}
+	}
+	if e.AllDay {
+		e.Start = time.Date(e.Start.Year(), e.Start.Month(), e.Start.Day(), 0, 0, 0, 0, b.location)
+		e.End = e.Start.AddDate(0, 0, 1)
+	} else {
+		e.End = e.Start.Add(b.cfg.EventDuration)
+		if b.cfg.WindowEndHour > 0 {
+			local := e.Start.In(b.location)
+			end := time.Date(local.Year(), local.Month(), local.Day(), b.cfg.WindowEndHour, 0, 0, 0, b.location)
+			if end.After(e.Start) {
+				e.End = end
+			}
+		}
+	}
+	return e, nil
+}
+
+// addEvent renders e as a VEVENT on cal.
+func (b *Builder) addEvent(cal *ics.Calendar, e Event) {
+	event := cal.AddEvent(e.UID)
+	event.SetSummary(e.Summary)
+	event.SetDescription(e.Description)
+	if e.Location != "" {
+		event.SetLocation(e.Location)
+	}
+	// The library escapes commas in values, so each category gets its own
+	// CATEGORIES property rather than a comma-separated list.
+	for _, category := range e.Categories {
+		event.AddProperty(ics.ComponentPropertyCategories, category)
+	}
+	if e.Color != "" {
+		event.SetColor(e.Color)
+	}
+	if b.cfg.Transparent {
+		event.SetTimeTransparency(ics.TransparencyTransparent)
+	}
+	if e.Status != "" {
+		event.SetStatus(ics.ObjectStatus(e.Status))
+	}
+
+	switch {
+	case e.AllDay:
+		event.SetAllDayStartAt(e.Start)
+		event.SetAllDayEndAt(e.End)
+	case e.Rule != "" || b.zoned():
+		// Local times referencing the VTIMEZONE keep events at the same wall
+		// clock hour across BST/GMT changes. Recurring events need this even
+		// without a VTIMEZONE, as a repeated UTC DTSTART drifts by an hour.
+		tzid := ics.WithTZID(b.cfg.Timezone)
+		event.SetProperty(ics.ComponentPropertyDtStart, e.Start.Format(localTimestampFormat), tzid)
+		event.SetProperty(ics.ComponentPropertyDtEnd, e.End.Format(localTimestampFormat), tzid)
+	default:
+		event.SetStartAt(e.Start)
+		event.SetEndAt(e.End)
+	}
+	if e.Rule != "" {
+		event.AddRrule(e.Rule)
+	}
+	event.SetDtStampTime(b.dtStamp(e.Start))
+
+	for _, trigger := range e.Alarms {
+		b.addAlarm(event, e.Summary, trigger)
+	}
+}
+
+// dtStamp returns the configured DTSTAMP, or fallback when none is set.
+func (b *Builder) dtStamp(fallback time.Time) time.Time {
+	if !b.cfg.DtStamp.IsZero() {
+		return b.cfg.DtStamp
+	}
+	return fallback
+}
+
+// groupByDay buckets the included collections by local calendar day, in date
+// order, keeping the order types were scraped within each day.
+func (b *Builder) groupByDay(collections []scraper.Collection) [][]scraper.Collection {
+	sorted := make([]scraper.Collection, 0, len(collections))
+	for _, c := range collections {
+		if b.includes(c.Type) {
+			sorted = append(sorted, c)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Date.Before(sorted[j].Date) })
+
+	var groups [][]scraper.Collection
+	lastDay := ""
+	for _, c := range sorted {
+		day := c.Date.In(b.location).Format("2006-01-02")
+		if day != lastDay {
+			groups = append(groups, nil)
+			lastDay = day
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], c)
+	}
+	return groups
+}
+
+// zoned reports whether events can reference a VTIMEZONE for the calendar zone.
+func (b *Builder) zoned() bool {
+	_, ok := vtimezones[b.cfg.Timezone]
+	return ok
+}
+
+// vtimezone returns the VTIMEZONE rules events reference: the built-in ones
+// for the calendar zone, or ones derived from the zone database when a
+// recurring event needs a zone without them. It returns nil when events keep
+// UTC times.
+func (b *Builder) vtimezone(events []Event) []tzTransition {
+	if rules, ok := vtimezones[b.cfg.Timezone]; ok {
+		return rules
+	}
+	for _, e := range events {
+		if e.Rule != "" {
+			return derivedTimezone(b.location, e.Start.In(b.location).Year())
+		}
+	}
+	return nil
+}
+
+// derivedTimezone builds VTIMEZONE rules for loc from the offset changes it
+// makes during year. Each change becomes a yearly rule on the same weekday
+// of its month, the way daylight saving is scheduled almost everywhere; a
+// zone with no changes gets a single fixed-offset STANDARD.
+func derivedTimezone(loc *time.Location, year int) []tzTransition {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	end := start.AddDate(1, 0, 0)
+	name, offset := start.Zone()
+
+	var rules []tzTransition
+	for prev, next := start, start.Add(24*time.Hour); prev.Before(end); prev, next = next, next.Add(24*time.Hour) {
+		_, before := prev.Zone()
+		if _, after := next.Zone(); after == before {
+			continue
+		}
+		// Narrow the change down to the second it happens.
+		lo, hi := prev, next
+		for hi.Sub(lo) > time.Second {
+			mid := lo.Add(hi.Sub(lo) / 2)
+			if _, o := mid.Zone(); o == before {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		at := hi.Truncate(time.Second).In(loc)
+		abbr, after := at.Zone()
+		local := at.In(time.FixedZone("", before))
+		n := (local.Day()-1)/7 + 1
+		if local.Day()+7 > daysIn(local.Year(), local.Month()) {
+			n = -1
+		}
+		onset := nthWeekday(1970, local.Month(), local.Weekday(), n)
+		rules = append(rules, tzTransition{
+			daylight:   at.IsDST(),
+			name:       abbr,
+			offsetFrom: formatOffset(before),
+			offsetTo:   formatOffset(after),
+			start:      onset.Format("20060102") + local.Format("T150405"),
+			rule:       fmt.Sprintf("FREQ=YEARLY;BYMONTH=%d;BYDAY=%d%s", local.Month(), n, weekdayCodes[local.Weekday()]),
+		})
+	}
+	if len(rules) == 0 {
+		rules = append(rules, tzTransition{
+			name:       name,
+			offsetFrom: formatOffset(offset),
+			offsetTo:   formatOffset(offset),
+			start:      "19700101T000000",
+		})
+	}
+	return rules
+}
+
+// weekdayCodes are the iCalendar BYDAY names, indexed by time.Weekday.
+var weekdayCodes = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// nthWeekday returns the nth weekday of month in year, counting back from
+// the end of the month when n is -1.
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	if n < 0 {
+		last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+		return last.AddDate(0, 0, -int((last.Weekday()-weekday+7)%7))
+	}
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	return first.AddDate(0, 0, int((weekday-first.Weekday()+7)%7)+7*(n-1))
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// formatOffset renders a UTC offset in seconds as a TZOFFSETFROM/TO value.
+func formatOffset(seconds int) string {
+	sign := '+'
+	if seconds < 0 {
+		sign, seconds = '-', -seconds
+	}
+	return fmt.Sprintf("%c%02d%02d", sign, seconds/3600, seconds%3600/60)
+}
+
+func addTimezone(cal *ics.Calendar, tzid string, rules []tzTransition) {
+	tz := cal.AddTimezone(tzid)
+	for _, t := range rules {
+		var c *ics.ComponentBase
+		if t.daylight {
+			daylight := &ics.Daylight{}
+			tz.Components = append(tz.Components, daylight)
+			c = &daylight.ComponentBase
+		} else {
+			c = &tz.AddStandard().ComponentBase
+		}
+		c.SetProperty(ics.ComponentProperty(ics.PropertyTzname), t.name)
+		c.SetProperty(ics.ComponentProperty(ics.PropertyTzoffsetfrom), t.offsetFrom)
+		c.SetProperty(ics.ComponentProperty(ics.PropertyTzoffsetto), t.offsetTo)
+		c.SetProperty(ics.ComponentPropertyDtStart, t.start)
+		if t.rule != "" {
+			c.AddRrule(t.rule)
+		}
+	}
+}
+
+// recurrence is a run of same-type collections expressible as one RRULE.
+type recurrence struct {
+	first scraper.Collection
+	rule  string
+}
+
+// recurringSeries finds the included types whose collections repeat exactly
+// every week or fortnight, at the same time and with the same note.
+func (b *Builder) recurringSeries(collections []scraper.Collection) map[string]recurrence {
+	byType := make(map[string][]scraper.Collection)
+	for _, c := range collections {
+		if b.includes(c.Type) {
+			byType[c.Type] = append(byType[c.Type], c)
+		}
+	}
+
+	series := make(map[string]recurrence)
+	for wasteType, run := range byType {
+		var interval int
+		switch run[0].Frequency {
+		case scraper.FrequencyWeekly:
+			interval = 1
+		case scraper.FrequencyFortnightly:
+			interval = 2
+		default:
+			continue
+		}
+		if len(run) < 2 {
+			continue
+		}
+
+		sort.Slice(run, func(i, j int) bool { return run[i].Date.Before(run[j].Date) })
+		regular := true
+		for i := 1; i < len(run); i++ {
+			want := run[i-1].Date.In(b.location).AddDate(0, 0, 7*interval)
+			if !run[i].Date.In(b.location).Equal(want) || run[i].Note != run[0].Note {
+				regular = false
+				break
+			}
+		}
+		if !regular {
+			continue
+		}
+
+		rule := fmt.Sprintf("FREQ=WEEKLY;COUNT=%d", len(run))
+		if interval > 1 {
+			rule = fmt.Sprintf("FREQ=WEEKLY;INTERVAL=%d;COUNT=%d", interval, len(run))
+		}
+		series[wasteType] = recurrence{first: run[0], rule: rule}
+	}
+	return series
 }
 
-func addAlarm(event *ics.VEvent, trigger string) {
+// displayName returns the translated or title-cased name of wasteType.
+func (b *Builder) displayName(wasteType string) string {
+	if translated, ok := b.cfg.TypeTranslations[wasteType]; ok {
+		return translated
+	}
+	return titleCase(wasteType)
+}
+
+// Summary renders the event title for the waste types collected together.
+func (b *Builder) Summary(wasteTypes []string) (string, error) {
+	names := make([]string, len(wasteTypes))
+	for i, t := range wasteTypes {
+		names[i] = b.displayName(t)
+	}
+	name := strings.Join(names, ", ")
+	if b.summary == nil {
+		if len(names) > 1 {
+			return fmt.Sprintf("Bins: %s", name), nil
+		}
+		return fmt.Sprintf("Bin: %s", name), nil
+	}
+
+	var out strings.Builder
+	if err := b.summary.Execute(&out, eventData{Type: name}); err != nil {
+		return "", fmt.Errorf("render summary: %w", err)
+	}
+	return out.String(), nil
+}
+
+func (b *Builder) includes(wasteType string) bool {
+	if len(b.cfg.Types) == 0 {
+		return true
+	}
+	for _, t := range b.cfg.Types {
+		if strings.EqualFold(t, wasteType) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupType finds wasteType in m, preferring an exact key over a
+// case-insensitive match.
+func lookupType(m map[string]string, wasteType string) (string, bool) {
+	if v, ok := m[wasteType]; ok {
+		return v, true
+	}
+	for k, v := range m {
+		if strings.EqualFold(k, wasteType) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// addAlarm adds a VALARM with the properties its action requires: AUDIO
+// needs none beyond the trigger, EMAIL also needs a SUMMARY and an ATTENDEE.
+func (b *Builder) addAlarm(event *ics.VEvent, summary, trigger string) {
+	action := ics.Action(b.cfg.AlarmAction)
 	alarm := event.AddAlarm()
-	alarm.SetAction(ics.ActionDisplay)
-	alarm.SetDescription("Bin reminder")
+	alarm.SetAction(action)
+	if action != ics.ActionAudio {
+		alarm.SetDescription("Bin reminder")
+	}
+	if action == ics.ActionEmail {
+		alarm.SetSummary(summary)
+		alarm.AddAttendee(b.cfg.AlarmEmail)
+	}
 	alarm.SetTrigger(trigger)
 }
 
-func eventDescription(collection scraper.Collection) string {
+func validTrigger(trigger string) bool {
+	if !triggerRegex.MatchString(trigger) {
+		return false
+	}
+	return !strings.HasSuffix(trigger, "T")
+}
+
+func (b *Builder) eventDescription(collection scraper.Collection) (string, error) {
 	instructionTexts, missedLinks, otherLinks := splitInstructions(collection.Instructions)
 	if len(instructionTexts) == 0 {
-		instructionTexts = []string{defaultInstruction}
+		var out strings.Builder
+		data := eventData{
+			Type: b.displayName(collection.Type),
+			Time: collection.Date.In(b.location).Format("15:04"),
+		}
+		if err := b.instruction.Execute(&out, data); err != nil {
+			return "", fmt.Errorf("render description: %w", err)
+		}
+		instructionTexts = []string{out.String()}
 	}
 
 	var sections []string
@@ -110,7 +754,7 @@ func eventDescription(collection scraper.Collection) string {
 		sections = append(sections, formatNoteSection(note))
 	}
 
-	return strings.Join(sections, "\n\n")
+	return strings.Join(sections, "\n\n"), nil
 }
 
 func splitInstructions(lines []scraper.Instruction) ([]string, []string, []string) {
@@ -227,12 +871,32 @@ func formatNoteSection(note string) string {
 	return b.String()
 }
 
-func eventID(collection scraper.Collection) string {
+// WithUPRN returns a copy of b whose event UIDs carry uprn, for serving
+// several properties from one configuration.
+func (b *Builder) WithUPRN(uprn string) *Builder {
+	scoped := *b
+	scoped.cfg.UPRN = uprn
+	return &scoped
+}
+
+// WithLocation returns a copy of b whose events carry location as their
+// LOCATION, or none when location is empty.
+func (b *Builder) WithLocation(location string) *Builder {
+	scoped := *b
+	scoped.cfg.Location = location
+	return &scoped
+}
+
+// EventID returns the stable UID of the event for a single collection,
+// without any UPRN.
+func EventID(collection scraper.Collection) string {
 	date := collection.Date.Format("20060102")
-	return fmt.Sprintf("%s-%s@redbridge-ics", slug(collection.Type), date)
+	return fmt.Sprintf("%s-%s@redbridge-ics", Slug(collection.Type), date)
 }
 
-func slug(value string) string {
+// Slug returns the lowercase, hyphenated form of value used in UIDs and in
+// per-type feed paths such as /calendar/garden-waste.ics.
+func Slug(value string) string {
 	lower := strings.ToLower(value)
 	lower = slugRegex.ReplaceAllString(lower, "-")
 	return strings.Trim(lower, "-")
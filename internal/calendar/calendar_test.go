@@ -1,6 +1,7 @@
 package calendar
 
 import (
+	"bytes"
 	"strings"
 	"testing"
 	"time"
@@ -54,6 +55,825 @@ func TestBuilderBuild(t *testing.T) {
 	mustContain(t, cal, "https://my.redbridge.gov.uk/MissedCollection/recycling")
 }
 
+func TestBuilderCustomAlarms(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/London")
+	b, err := NewBuilder(Config{
+		Name:     "Redbridge Collections",
+		Timezone: "Europe/London",
+		Alarms:   []string{"-PT10H", "-PT2H", "-P1D"},
+	})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+
+	data, err := b.Build([]scraper.Collection{
+		{Date: time.Date(2025, time.December, 2, 6, 0, 0, 0, loc), Type: "Refuse"},
+	})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	cal := unfoldICS(string(data))
+	if got := strings.Count(cal, "BEGIN:VALARM"); got != 3 {
+		t.Fatalf("expected 3 alarms, got %d", got)
+	}
+	mustContain(t, cal, "TRIGGER:-PT10H")
+	mustContain(t, cal, "TRIGGER:-PT2H")
+	mustContain(t, cal, "TRIGGER:-P1D")
+	if strings.Contains(cal, "TRIGGER:-PT11H") {
+		t.Fatalf("default alarm should be replaced by configured alarms")
+	}
+}
+
+func TestBuilderEventDuration(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/London")
+	b, err := NewBuilder(Config{
+		Name:          "Redbridge Collections",
+		Timezone:      "Europe/London",
+		EventDuration: 90 * time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+
+	data, err := b.Build([]scraper.Collection{
+		{Date: time.Date(2025, time.December, 2, 6, 0, 0, 0, loc), Type: "Refuse"},
+	})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	cal := unfoldICS(string(data))
+	mustContain(t, cal, "DTSTART;TZID=Europe/London:20251202T060000")
+	mustContain(t, cal, "DTEND;TZID=Europe/London:20251202T073000")
+}
+
+func TestBuilderCollectionWindow(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/London")
+	b, err := NewBuilder(Config{
+		Name:          "Redbridge Collections",
+		Timezone:      "Europe/London",
+		WindowEndHour: 16,
+	})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+
+	events, err := b.Events([]scraper.Collection{
+		{Date: time.Date(2025, time.December, 2, 6, 0, 0, 0, loc), Type: "Refuse"},
+		{Date: time.Date(2026, time.July, 7, 5, 0, 0, 0, loc), Type: "Food Waste"},
+		{Date: time.Date(2026, time.July, 8, 17, 0, 0, 0, loc), Type: "Recycling"},
+	})
+	if err != nil {
+		t.Fatalf("Events: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	want := []time.Time{
+		time.Date(2025, time.December, 2, 16, 0, 0, 0, loc),
+		time.Date(2026, time.July, 7, 16, 0, 0, 0, loc),
+		// Starting after the window closes falls back to EventDuration.
+		time.Date(2026, time.July, 8, 18, 0, 0, 0, loc),
+	}
+	for i, e := range events {
+		if !e.End.Equal(want[i]) {
+			t.Fatalf("event %d (%s) ends %s, want %s", i, e.Summary, e.End, want[i])
+		}
+	}
+
+	data, err := b.Build([]scraper.Collection{
+		{Date: time.Date(2025, time.December, 2, 6, 0, 0, 0, loc), Type: "Refuse"},
+	})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	cal := unfoldICS(string(data))
+	mustContain(t, cal, "DTSTART;TZID=Europe/London:20251202T060000")
+	mustContain(t, cal, "DTEND;TZID=Europe/London:20251202T160000")
+
+	if _, err := NewBuilder(Config{Name: "Redbridge Collections", WindowEndHour: 25}); err == nil {
+		t.Fatalf("expected error for window end hour 25")
+	}
+}
+
+func TestBuilderAllDay(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/London")
+	b, err := NewBuilder(Config{
+		Name:     "Redbridge Collections",
+		Timezone: "Europe/London",
+		AllDay:   true,
+	})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+
+	data, err := b.Build([]scraper.Collection{
+		{Date: time.Date(2025, time.December, 2, 6, 0, 0, 0, loc), Type: "Refuse"},
+	})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	cal := unfoldICS(string(data))
+	mustContain(t, cal, "DTSTART;VALUE=DATE:20251202")
+	mustContain(t, cal, "DTEND;VALUE=DATE:20251203")
+	if strings.Contains(cal, "DTSTART:") {
+		t.Fatalf("expected no timed DTSTART in all-day mode")
+	}
+}
+
+func TestBuilderTimezone(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/London")
+	b, err := NewBuilder(Config{
+		Name:     "Redbridge Collections",
+		Timezone: "Europe/London",
+	})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+
+	data, err := b.Build([]scraper.Collection{
+		{Date: time.Date(2025, time.December, 2, 6, 0, 0, 0, loc), Type: "Refuse"},
+		{Date: time.Date(2026, time.June, 2, 6, 0, 0, 0, loc), Type: "Refuse"},
+	})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	cal := unfoldICS(string(data))
+	mustContain(t, cal, "BEGIN:VTIMEZONE")
+	mustContain(t, cal, "TZID:Europe/London")
+	mustContain(t, cal, "BEGIN:DAYLIGHT")
+	mustContain(t, cal, "TZNAME:BST")
+	mustContain(t, cal, "BEGIN:STANDARD")
+	mustContain(t, cal, "TZNAME:GMT")
+	// Both winter and summer collections stay at 06:00 local time.
+	mustContain(t, cal, "DTSTART;TZID=Europe/London:20251202T060000")
+	mustContain(t, cal, "DTSTART;TZID=Europe/London:20260602T060000")
+	if strings.Contains(cal, "DTSTART:2025") {
+		t.Fatalf("expected no UTC DTSTART once a VTIMEZONE is present")
+	}
+}
+
+func TestBuilderGroupByDay(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/London")
+	b, err := NewBuilder(Config{
+		Name:           "Redbridge Collections",
+		Timezone:       "Europe/London",
+		GroupByDay:     true,
+		CategoryColors: map[string]string{"Recycling": "blue"},
+	})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+
+	data, err := b.Build([]scraper.Collection{
+		{Date: time.Date(2025, time.December, 2, 6, 0, 0, 0, loc), Type: "Refuse"},
+		{Date: time.Date(2025, time.December, 2, 6, 0, 0, 0, loc), Type: "Recycling", Note: "Rinse containers."},
+		{Date: time.Date(2025, time.December, 9, 6, 0, 0, 0, loc), Type: "Refuse"},
+	})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	cal := unfoldICS(string(data))
+	if got := strings.Count(cal, "BEGIN:VEVENT"); got != 2 {
+		t.Fatalf("expected one event per day, got %d", got)
+	}
+	if got := strings.Count(cal, "BEGIN:VALARM"); got != 4 {
+		t.Fatalf("expected alarms once per day, got %d", got)
+	}
+	mustContain(t, cal, "UID:bins-20251202@redbridge-ics")
+	mustContain(t, cal, `SUMMARY:Bins: Refuse\, Recycling`)
+	mustContain(t, cal, "CATEGORIES:Refuse")
+	mustContain(t, cal, "CATEGORIES:Recycling")
+	mustContain(t, cal, "COLOR:blue")
+	mustContain(t, cal, "RECYCLING")
+	mustContain(t, cal, "Rinse containers.")
+	mustContain(t, cal, "UID:refuse-20251209@redbridge-ics")
+	mustContain(t, cal, "SUMMARY:Bin: Refuse")
+}
+
+func TestBuilderRecurrence(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/London")
+	b, err := NewBuilder(Config{
+		Name:          "Redbridge Collections",
+		Timezone:      "Europe/London",
+		UseRecurrence: true,
+	})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+
+	var collections []scraper.Collection
+	for i := 0; i < 4; i++ {
+		collections = append(collections, scraper.Collection{
+			Date:      time.Date(2025, time.December, 2+14*i, 6, 0, 0, 0, loc),
+			Type:      "Recycling",
+			Frequency: scraper.FrequencyFortnightly,
+		})
+	}
+	collections = append(collections,
+		scraper.Collection{Date: time.Date(2025, time.December, 3, 6, 0, 0, 0, loc), Type: "Garden Waste", Frequency: scraper.FrequencyIrregular},
+		scraper.Collection{Date: time.Date(2025, time.December, 20, 6, 0, 0, 0, loc), Type: "Garden Waste", Frequency: scraper.FrequencyIrregular},
+	)
+
+	data, err := b.Build(collections)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	cal := unfoldICS(string(data))
+	mustContain(t, cal, "RRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=4")
+	mustContain(t, cal, "DTSTART;TZID=Europe/London:20251202T060000")
+	if got := strings.Count(cal, "BEGIN:VEVENT"); got != 3 {
+		t.Fatalf("expected 1 recurring + 2 discrete events, got %d", got)
+	}
+	if got := strings.Count(cal, "RRULE:FREQ=WEEKLY"); got != 1 {
+		t.Fatalf("expected irregular type to stay discrete, got %d RRULEs", got)
+	}
+}
+
+func TestBuilderRecurrenceDerivedTimezone(t *testing.T) {
+	loc, _ := time.LoadLocation("America/New_York")
+	b, err := NewBuilder(Config{
+		Name:          "Redbridge Collections",
+		Timezone:      "America/New_York",
+		UseRecurrence: true,
+	})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+
+	var collections []scraper.Collection
+	for i := 0; i < 4; i++ {
+		collections = append(collections, scraper.Collection{
+			Date:      time.Date(2025, time.December, 2+7*i, 6, 0, 0, 0, loc),
+			Type:      "Refuse",
+			Frequency: scraper.FrequencyWeekly,
+		})
+	}
+	data, err := b.Build(collections)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	// The TZID the recurring DTSTART uses must have a VTIMEZONE.
+	cal := unfoldICS(string(data))
+	mustContain(t, cal, "DTSTART;TZID=America/New_York:20251202T060000")
+	mustContain(t, cal, "BEGIN:VTIMEZONE")
+	mustContain(t, cal, "TZID:America/New_York")
+	mustContain(t, cal, "TZNAME:EDT")
+	mustContain(t, cal, "RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=2SU")
+	mustContain(t, cal, "DTSTART:19700308T020000")
+	mustContain(t, cal, "TZNAME:EST")
+	mustContain(t, cal, "RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=1SU")
+	mustContain(t, cal, "DTSTART:19701101T020000")
+
+	// Without recurrence the events keep UTC times and need no VTIMEZONE.
+	b, err = NewBuilder(Config{Name: "Redbridge Collections", Timezone: "America/New_York"})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+	data, err = b.Build(collections)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if strings.Contains(string(data), "BEGIN:VTIMEZONE") {
+		t.Fatalf("expected no VTIMEZONE for UTC events, got %s", data)
+	}
+}
+
+func TestDerivedTimezone(t *testing.T) {
+	london, _ := time.LoadLocation("Europe/London")
+	got := derivedTimezone(london, 2025)
+	want := vtimezones["Europe/London"]
+	if len(got) != len(want) {
+		t.Fatalf("derived %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("rule %d: derived %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	got = derivedTimezone(tokyo, 2025)
+	if len(got) != 1 || got[0].offsetFrom != "+0900" || got[0].offsetTo != "+0900" || got[0].rule != "" {
+		t.Fatalf("expected one fixed +0900 rule, got %+v", got)
+	}
+
+	kolkata, _ := time.LoadLocation("Asia/Kolkata")
+	if got := derivedTimezone(kolkata, 2025); got[0].offsetTo != "+0530" {
+		t.Fatalf("expected +0530, got %+v", got)
+	}
+}
+
+func TestBuilderCategoryColors(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/London")
+	b, err := NewBuilder(Config{
+		Name:           "Redbridge Collections",
+		Timezone:       "Europe/London",
+		CategoryColors: map[string]string{"refuse": "black"},
+		Categories:     map[string]string{"Recycling": "Blue Bin"},
+	})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+
+	data, err := b.Build([]scraper.Collection{
+		{Date: time.Date(2025, time.December, 2, 6, 0, 0, 0, loc), Type: "Refuse"},
+		{Date: time.Date(2025, time.December, 2, 6, 0, 0, 0, loc), Type: "Recycling"},
+	})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	cal := unfoldICS(string(data))
+	mustContain(t, cal, "COLOR:black")
+	mustContain(t, cal, "CATEGORIES:Refuse")
+	mustContain(t, cal, "CATEGORIES:Blue Bin")
+	if got := strings.Count(cal, "COLOR:"); got != 1 {
+		t.Fatalf("expected COLOR only on the configured type, got %d", got)
+	}
+}
+
+func TestBuilderTransparent(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/London")
+	collections := []scraper.Collection{
+		{Date: time.Date(2025, time.December, 2, 6, 0, 0, 0, loc), Type: "Refuse"},
+		{Date: time.Date(2025, time.December, 9, 6, 0, 0, 0, loc), Type: "Refuse"},
+	}
+
+	b, err := NewBuilder(Config{
+		Name:        "Redbridge Collections",
+		Timezone:    "Europe/London",
+		Transparent: true,
+	})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+	data, err := b.Build(collections)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	cal := unfoldICS(string(data))
+	if got := strings.Count(cal, "TRANSP:TRANSPARENT"); got != 2 {
+		t.Fatalf("expected TRANSP:TRANSPARENT on both events, got %d", got)
+	}
+
+	b, err = NewBuilder(Config{Name: "Redbridge Collections", Timezone: "Europe/London"})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+	data, err = b.Build(collections)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if strings.Contains(string(data), "TRANSP:") {
+		t.Fatalf("expected no TRANSP property when disabled")
+	}
+}
+
+func TestBuilderReproducible(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/London")
+	collections := []scraper.Collection{
+		{Date: time.Date(2025, time.December, 2, 6, 0, 0, 0, loc), Type: "Refuse"},
+		{Date: time.Date(2025, time.December, 3, 6, 0, 0, 0, loc), Type: "Recycling"},
+	}
+
+	b, err := NewBuilder(Config{Name: "Redbridge Collections", Timezone: "Europe/London"})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+	first, err := b.Build(collections)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	second, err := b.Build(collections)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Fatalf("expected identical builds, got\n%s\n---\n%s", first, second)
+	}
+	if !strings.Contains(string(first), "DTSTAMP:20251202T060000Z") {
+		t.Fatalf("expected DTSTAMP to follow the event start, got %s", first)
+	}
+
+	stamp := time.Date(2025, time.November, 30, 12, 0, 0, 0, time.UTC)
+	b, err = NewBuilder(Config{Name: "Redbridge Collections", Timezone: "Europe/London", DtStamp: stamp})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+	data, err := b.Build(collections)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if got := strings.Count(string(data), "DTSTAMP:20251130T120000Z"); got != 2 {
+		t.Fatalf("expected configured DTSTAMP on both events, got %d", got)
+	}
+}
+
+func TestBuilderMaxEvents(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/London")
+	collections := []scraper.Collection{
+		{Date: time.Date(2025, time.December, 9, 6, 0, 0, 0, loc), Type: "Refuse"},
+		{Date: time.Date(2025, time.December, 2, 6, 0, 0, 0, loc), Type: "Refuse"},
+		{Date: time.Date(2025, time.December, 3, 6, 0, 0, 0, loc), Type: "Garden Waste"},
+		{Date: time.Date(2025, time.December, 3, 6, 0, 0, 0, loc), Type: "Recycling"},
+		{Date: time.Date(2025, time.December, 10, 6, 0, 0, 0, loc), Type: "Recycling"},
+		{Date: time.Date(2025, time.December, 16, 6, 0, 0, 0, loc), Type: "Refuse"},
+	}
+
+	b, err := NewBuilder(Config{
+		Name:      "Redbridge Collections",
+		Timezone:  "Europe/London",
+		Types:     []string{"Refuse", "Recycling"},
+		MaxEvents: 3,
+	})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+	data, err := b.Build(collections)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	cal := string(data)
+	if got := strings.Count(cal, "BEGIN:VEVENT"); got != 3 {
+		t.Fatalf("expected 3 events, got %d", got)
+	}
+	for _, uid := range []string{"refuse-20251202", "recycling-20251203", "refuse-20251209"} {
+		if !strings.Contains(cal, "UID:"+uid+"@redbridge-ics") {
+			t.Fatalf("expected earliest event %s in feed", uid)
+		}
+	}
+	if strings.Contains(cal, "garden-waste") || strings.Contains(cal, "recycling-20251210") {
+		t.Fatalf("expected later and filtered events to be dropped")
+	}
+
+	events, err := b.Events(collections)
+	if err != nil {
+		t.Fatalf("Events: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected Events to honour MaxEvents, got %d", len(events))
+	}
+	if events[0].UID != "refuse-20251202@redbridge-ics" || events[2].UID != "refuse-20251209@redbridge-ics" {
+		t.Fatalf("expected earliest events in start order, got %s..%s", events[0].UID, events[2].UID)
+	}
+}
+
+func TestBuilderLocation(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/London")
+	collections := []scraper.Collection{
+		{Date: time.Date(2025, time.December, 2, 6, 0, 0, 0, loc), Type: "Refuse"},
+		{Date: time.Date(2025, time.December, 3, 6, 0, 0, 0, loc), Type: "Recycling"},
+	}
+
+	b, err := NewBuilder(Config{
+		Name:     "Redbridge Collections",
+		Timezone: "Europe/London",
+		Location: "123 Sample Street, IG1 1AA",
+	})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+	data, err := b.Build(collections)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	cal := unfoldICS(string(data))
+	if got := strings.Count(cal, `LOCATION:123 Sample Street\, IG1 1AA`); got != 2 {
+		t.Fatalf("expected LOCATION on both events, got %d in %s", got, cal)
+	}
+
+	b, err = NewBuilder(Config{Name: "Redbridge Collections", Timezone: "Europe/London"})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+	data, err = b.Build(collections)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if strings.Contains(string(data), "LOCATION:") {
+		t.Fatalf("expected no LOCATION by default")
+	}
+}
+
+func TestBuilderEventStatus(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/London")
+	collections := []scraper.Collection{
+		{Date: time.Date(2025, time.December, 2, 6, 0, 0, 0, loc), Type: "Refuse"},
+		{Date: time.Date(2025, time.December, 9, 6, 0, 0, 0, loc), Type: "Refuse" + scraper.NoCollectionSuffix, Note: "No collection this week."},
+	}
+
+	b, err := NewBuilder(Config{Name: "Redbridge Collections", Timezone: "Europe/London", EventStatus: true})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+	data, err := b.Build(collections)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	cal := unfoldICS(string(data))
+	events := strings.Split(cal, "BEGIN:VEVENT")[1:]
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if !strings.Contains(events[0], "STATUS:CONFIRMED") {
+		t.Fatalf("expected the normal event CONFIRMED, got %s", events[0])
+	}
+	if !strings.Contains(events[1], "STATUS:CANCELLED") {
+		t.Fatalf("expected the skipped event CANCELLED, got %s", events[1])
+	}
+
+	// A day with one real collection still happens.
+	grouped, err := NewBuilder(Config{Name: "Redbridge Collections", Timezone: "Europe/London", EventStatus: true, GroupByDay: true})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+	sameDay := append(collections[1:], scraper.Collection{Date: collections[1].Date, Type: "Recycling"})
+	data, err = grouped.Build(sameDay)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if !strings.Contains(string(data), "STATUS:CONFIRMED") {
+		t.Fatalf("expected a mixed day CONFIRMED, got %s", data)
+	}
+
+	b, err = NewBuilder(Config{Name: "Redbridge Collections", Timezone: "Europe/London"})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+	data, err = b.Build(collections)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if strings.Contains(string(data), "STATUS:") {
+		t.Fatalf("expected no STATUS by default")
+	}
+}
+
+func TestBuilderUPRNInUID(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/London")
+	collections := []scraper.Collection{
+		{Date: time.Date(2025, time.December, 2, 6, 0, 0, 0, loc), Type: "Refuse"},
+	}
+
+	b, err := NewBuilder(Config{Name: "Redbridge Collections", Timezone: "Europe/London"})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+	uids := map[string]string{}
+	for _, uprn := range []string{"", "100001", "100002"} {
+		events, err := b.WithUPRN(uprn).Events(collections)
+		if err != nil {
+			t.Fatalf("Events: %v", err)
+		}
+		uids[uprn] = events[0].UID
+	}
+	if uids[""] != "refuse-20251202@redbridge-ics" {
+		t.Fatalf("expected the UID without a UPRN unchanged, got %q", uids[""])
+	}
+	if uids["100001"] != "refuse-20251202-100001@redbridge-ics" || uids["100001"] == uids["100002"] {
+		t.Fatalf("expected distinct UIDs per UPRN, got %v", uids)
+	}
+
+	grouped, err := NewBuilder(Config{Name: "Redbridge Collections", Timezone: "Europe/London", GroupByDay: true, UPRN: "100001"})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+	data, err := grouped.Build(append(collections, scraper.Collection{Date: collections[0].Date, Type: "Recycling"}))
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if !strings.Contains(string(data), "UID:bins-20251202-100001@redbridge-ics") {
+		t.Fatalf("expected the UPRN in the grouped UID, got %s", data)
+	}
+}
+
+func TestBuilderFreeBusy(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/London")
+	b, err := NewBuilder(Config{Name: "Redbridge Collections", Timezone: "Europe/London"})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+	collections := []scraper.Collection{
+		{Date: time.Date(2025, time.December, 2, 6, 0, 0, 0, loc), Type: "Refuse"},
+		{Date: time.Date(2025, time.December, 2, 6, 0, 0, 0, loc), Type: "Recycling"},
+		{Date: time.Date(2026, time.June, 9, 6, 0, 0, 0, loc), Type: "Refuse"},
+	}
+
+	data, err := b.FreeBusy(collections, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("FreeBusy: %v", err)
+	}
+	cal := unfoldICS(string(data))
+	mustContain(t, cal, "BEGIN:VFREEBUSY")
+	mustContain(t, cal, "DTSTART:20251202T060000Z")
+	mustContain(t, cal, "DTEND:20260609T060000Z")
+	mustContain(t, cal, "FREEBUSY;FBTYPE=BUSY:20251202T060000Z/20251202T070000Z")
+	// June is BST, so 06:00 local is 05:00 UTC.
+	mustContain(t, cal, "FREEBUSY;FBTYPE=BUSY:20260609T050000Z/20260609T060000Z")
+	if got := strings.Count(cal, "FREEBUSY;"); got != 2 {
+		t.Fatalf("expected same-hour collections merged into 2 periods, got %d", got)
+	}
+
+	data, err = b.FreeBusy(collections, time.Date(2026, time.January, 1, 0, 0, 0, 0, loc), time.Date(2026, time.December, 31, 0, 0, 0, 0, loc))
+	if err != nil {
+		t.Fatalf("FreeBusy: %v", err)
+	}
+	if got := strings.Count(unfoldICS(string(data)), "FREEBUSY;"); got != 1 {
+		t.Fatalf("expected the range to drop December's period, got %d periods", got)
+	}
+}
+
+func TestBuilderTypesAllowList(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/London")
+	b, err := NewBuilder(Config{
+		Name:     "Redbridge Collections",
+		Timezone: "Europe/London",
+		Types:    []string{"refuse", "Recycling"},
+	})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+
+	data, err := b.Build([]scraper.Collection{
+		{Date: time.Date(2025, time.December, 2, 6, 0, 0, 0, loc), Type: "Refuse"},
+		{Date: time.Date(2025, time.December, 2, 6, 0, 0, 0, loc), Type: "Recycling"},
+		{Date: time.Date(2025, time.December, 3, 6, 0, 0, 0, loc), Type: "Garden Waste"},
+	})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	cal := unfoldICS(string(data))
+	mustContain(t, cal, "UID:refuse-20251202@redbridge-ics")
+	mustContain(t, cal, "UID:recycling-20251202@redbridge-ics")
+	if strings.Contains(cal, "garden-waste") {
+		t.Fatalf("expected garden waste to be excluded")
+	}
+}
+
+func TestBuilderSummaryTemplate(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/London")
+	b, err := NewBuilder(Config{
+		Name:            "Redbridge Collections",
+		Timezone:        "Europe/London",
+		SummaryTemplate: "Mülltonne: {{.Type}}",
+		TypeTranslations: map[string]string{
+			"Refuse": "Restmüll",
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+
+	data, err := b.Build([]scraper.Collection{
+		{Date: time.Date(2025, time.December, 2, 6, 0, 0, 0, loc), Type: "Refuse"},
+		{Date: time.Date(2025, time.December, 2, 6, 0, 0, 0, loc), Type: "garden waste"},
+	})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	cal := unfoldICS(string(data))
+	mustContain(t, cal, "SUMMARY:Mülltonne: Restmüll")
+	mustContain(t, cal, "SUMMARY:Mülltonne: Garden Waste")
+}
+
+func TestBuilderDescriptionStartHour(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/London")
+	b, err := NewBuilder(Config{
+		Name:     "Redbridge Collections",
+		Timezone: "Europe/London",
+	})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+
+	data, err := b.Build([]scraper.Collection{
+		{Date: time.Date(2025, time.December, 2, 7, 0, 0, 0, loc), Type: "Refuse"},
+	})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	mustContain(t, unfoldICS(string(data)), "• Place bins out by 07:00 on collection day.")
+
+	b, err = NewBuilder(Config{
+		Name:             "Redbridge Collections",
+		Timezone:         "Europe/London",
+		EventDescription: "{{.Type}} bin out before {{.Time}}",
+	})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+	data, err = b.Build([]scraper.Collection{
+		{Date: time.Date(2026, time.June, 2, 7, 0, 0, 0, loc), Type: "garden waste"},
+	})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	mustContain(t, unfoldICS(string(data)), "• Garden Waste bin out before 07:00")
+}
+
+func TestNewBuilderRejectsInvalidSummaryTemplate(t *testing.T) {
+	for _, tmpl := range []string{"{{.Type", "{{.Missing}}"} {
+		_, err := NewBuilder(Config{
+			Name:            "Redbridge Collections",
+			SummaryTemplate: tmpl,
+		})
+		if err == nil {
+			t.Fatalf("expected error for template %q", tmpl)
+		}
+	}
+}
+
+func TestNewBuilderRejectsInvalidDescription(t *testing.T) {
+	_, err := NewBuilder(Config{
+		Name:             "Redbridge Collections",
+		EventDescription: "out by {{.Hour}}",
+	})
+	if err == nil {
+		t.Fatalf("expected error for unknown template field")
+	}
+}
+
+func TestBuilderAlarmAction(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/London")
+	collections := []scraper.Collection{
+		{Date: time.Date(2025, time.December, 2, 6, 0, 0, 0, loc), Type: "Refuse"},
+	}
+	build := func(cfg Config) string {
+		t.Helper()
+		cfg.Name = "Redbridge Collections"
+		b, err := NewBuilder(cfg)
+		if err != nil {
+			t.Fatalf("NewBuilder: %v", err)
+		}
+		data, err := b.Build(collections)
+		if err != nil {
+			t.Fatalf("Build: %v", err)
+		}
+		return unfoldICS(string(data))
+	}
+	alarms := func(cal string) string {
+		return cal[strings.Index(cal, "BEGIN:VALARM"):strings.LastIndex(cal, "END:VALARM")]
+	}
+
+	display := alarms(build(Config{}))
+	mustContain(t, display, "ACTION:DISPLAY")
+	mustContain(t, display, "DESCRIPTION:Bin reminder")
+	if strings.Contains(display, "ATTENDEE") {
+		t.Fatalf("display alarm should have no attendee:\n%s", display)
+	}
+
+	audio := alarms(build(Config{AlarmAction: "Audio"}))
+	if got := strings.Count(audio, "ACTION:AUDIO"); got != 2 {
+		t.Fatalf("expected 2 audio alarms, got %d:\n%s", got, audio)
+	}
+	if strings.Contains(audio, "DESCRIPTION") || strings.Contains(audio, "ACTION:DISPLAY") {
+		t.Fatalf("audio alarm should only carry ACTION and TRIGGER:\n%s", audio)
+	}
+
+	email := alarms(build(Config{AlarmAction: "email", AlarmEmail: "Bins <bins@example.com>"}))
+	if got := strings.Count(email, "ACTION:EMAIL"); got != 2 {
+		t.Fatalf("expected 2 email alarms, got %d:\n%s", got, email)
+	}
+	mustContain(t, email, "ATTENDEE:mailto:bins@example.com")
+	mustContain(t, email, "SUMMARY:Bin: Refuse")
+	mustContain(t, email, "DESCRIPTION:Bin reminder")
+	mustContain(t, email, "TRIGGER:-PT11H")
+}
+
+func TestNewBuilderRejectsInvalidAlarmAction(t *testing.T) {
+	for _, cfg := range []Config{
+		{AlarmAction: "procedure"},
+		{AlarmAction: "email"},
+		{AlarmAction: "email", AlarmEmail: "not an address"},
+	} {
+		cfg.Name = "Redbridge Collections"
+		if _, err := NewBuilder(cfg); err == nil {
+			t.Fatalf("expected error for action %q email %q", cfg.AlarmAction, cfg.AlarmEmail)
+		}
+	}
+}
+
+func TestNewBuilderRejectsInvalidAlarm(t *testing.T) {
+	for _, trigger := range []string{"", "11H", "-PT", "-P", "PT1X"} {
+		_, err := NewBuilder(Config{
+			Name:   "Redbridge Collections",
+			Alarms: []string{trigger},
+		})
+		if err == nil {
+			t.Fatalf("expected error for trigger %q", trigger)
+		}
+	}
+}
+
 func mustContain(t *testing.T, haystack, needle string) {
 	t.Helper()
 	if !strings.Contains(haystack, needle) {
@@ -0,0 +1,97 @@
+package calendar
+
+import (
+	"sort"
+	"time"
+
+	ics "github.com/arran4/golang-ical"
+
+	"github.com/Takenobou/redbridge-council-rubbish-scraper/internal/scraper"
+)
+
+const freeBusyTimestampFormat = "20060102T150405Z"
+
+// busyPeriod is one FREEBUSY interval, in UTC as RFC 5545 requires.
+type busyPeriod struct {
+	start time.Time
+	end   time.Time
+}
+
+// FreeBusy renders a VFREEBUSY marking each included collection as busy for
+// its event duration (or the whole local day for all-day feeds). The output
+// covers from..to; a zero bound falls back to the first or last collection.
+func (b *Builder) FreeBusy(collections []scraper.Collection, from, to time.Time) ([]byte, error) {
+	periods := b.busyPeriods(collections)
+	if from.IsZero() && len(periods) > 0 {
+		from = periods[0].start
+	}
+	if to.IsZero() && len(periods) > 0 {
+		to = periods[len(periods)-1].end
+	}
+
+	cal := ics.NewCalendar()
+	cal.SetProductId(productID)
+	cal.SetMethod(ics.MethodPublish)
+
+	fb := cal.AddBusy("freebusy@redbridge-ics")
+	stamp := b.dtStamp(from)
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+	fb.SetDtStampTime(stamp)
+	if !from.IsZero() {
+		fb.SetProperty(ics.ComponentPropertyDtStart, from.UTC().Format(freeBusyTimestampFormat))
+	}
+	if !to.IsZero() {
+		fb.SetProperty(ics.ComponentPropertyDtEnd, to.UTC().Format(freeBusyTimestampFormat))
+	}
+
+	busy := &ics.KeyValues{Key: string(ics.ParameterFbtype), Value: []string{string(ics.FreeBusyTimeTypeBusy)}}
+	for _, p := range periods {
+		// Clip to the requested window, dropping periods entirely outside it.
+		if !from.IsZero() && p.start.Before(from) {
+			p.start = from
+		}
+		if !to.IsZero() && p.end.After(to) {
+			p.end = to
+		}
+		if !p.end.After(p.start) {
+			continue
+		}
+		value := p.start.UTC().Format(freeBusyTimestampFormat) + "/" + p.end.UTC().Format(freeBusyTimestampFormat)
+		fb.AddProperty(ics.ComponentPropertyFreebusy, value, busy)
+	}
+
+	return []byte(cal.Serialize()), nil
+}
+
+// busyPeriods returns the sorted busy intervals for the included collections,
+// merging overlapping ones so types collected together appear once.
+func (b *Builder) busyPeriods(collections []scraper.Collection) []busyPeriod {
+	var periods []busyPeriod
+	for _, c := range collections {
+		if !b.includes(c.Type) {
+			continue
+		}
+		start := c.Date.In(b.location)
+		end := start.Add(b.cfg.EventDuration)
+		if b.cfg.AllDay {
+			start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, b.location)
+			end = start.AddDate(0, 0, 1)
+		}
+		periods = append(periods, busyPeriod{start: start, end: end})
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].start.Before(periods[j].start) })
+
+	var merged []busyPeriod
+	for _, p := range periods {
+		if n := len(merged); n > 0 && !p.start.After(merged[n-1].end) {
+			if p.end.After(merged[n-1].end) {
+				merged[n-1].end = p.end
+			}
+			continue
+		}
+		merged = append(merged, p)
+	}
+	return merged
+}
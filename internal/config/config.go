@@ -3,10 +3,16 @@ package config
 import (
 	"errors"
 	"fmt"
+	"log/slog"
+	"math"
+	"net/netip"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/Takenobou/redbridge-council-rubbish-scraper/internal/scraper"
 )
 
 const (
@@ -16,6 +22,21 @@ const (
 	defaultCacheTTL      = 168 * time.Hour
 	defaultRequestTimout = 15 * time.Second
 	defaultStartHour     = 6
+	defaultEventDuration = time.Hour
+	defaultMaxRetries    = 2
+	defaultRetryDelay    = 200 * time.Millisecond
+	defaultRequestDelay  = 150 * time.Millisecond
+	defaultConcurrency   = 2
+	defaultMaxBodyBytes  = 5 << 20
+	defaultMaxIdle       = 100
+	defaultMaxIdlePer    = 4
+	defaultIdleTimeout   = 90 * time.Second
+	defaultStreamTick    = time.Minute
+	defaultTodayWindow   = time.Hour
+	defaultBreakerFails  = 5
+	defaultBreakerCool   = time.Minute
+	defaultWebhookFails  = 3
+	defaultWebhookWait   = 10 * time.Second
 	defaultListenAddr    = ":8080"
 	londonTimezone       = "Europe/London"
 	calendarName         = "Redbridge Collections"
@@ -24,36 +45,386 @@ const (
 
 // Config centralises 12-factor friendly runtime configuration.
 type Config struct {
-	ListenAddr     string
-	BaseURL        string
-	SchedulePath   string
-	UPRN           string
-	AddressLine    string
-	Postcode       string
-	Latitude       string
-	Longitude      string
-	CacheTTL       time.Duration
-	StartHour      int
-	UserAgent      string
-	RequestTimeout time.Duration
-	Timezone       string
-	CalendarName   string
-	CalendarDesc   string
-}
-
-// Load builds the Config using environment variables.
+	ListenAddr        string
+	BaseURL           string
+	SchedulePath      string
+	UPRN              string
+	UPRNs             []string
+	AddressLine       string
+	Postcode          string
+	Latitude          string
+	Longitude         string
+	CacheTTL          time.Duration
+	CacheFile         string
+	MinFreshness      time.Duration
+	RefreshInterval   time.Duration
+	Horizon           time.Duration
+	StartHour         int
+	StartHourByType   map[string]int
+	WindowEndHour     int
+	UserAgent         string
+	UserAgents        []string
+	ProxyURL          string
+	OriginUsername    string
+	OriginPassword    string
+	SaveAddressMethod string
+	RequestTimeout    time.Duration
+	MaxRetries        int
+	RetryBaseDelay    time.Duration
+	MaxBodyBytes      int64
+	MaxIdleConns      int
+	MaxIdlePerHost    int
+	IdleConnTimeout   time.Duration
+	RequestDelay      time.Duration
+	ScrapeConcurrency int
+	ScrapeDays        []time.Weekday
+	StreamInterval    time.Duration
+	TodayWindow       time.Duration
+	BreakerThreshold  int
+	BreakerCooldown   time.Duration
+	FailureWebhook    string
+	WebhookThreshold  int
+	WebhookTimeout    time.Duration
+	Timezone          string
+	CalendarName      string
+	CalendarDesc      string
+	CalendarTypes     []string
+	MaxEvents         int
+	SummaryTemplate   string
+	EventDescription  string
+	TypeTranslations  map[string]string
+	TypeAliases       map[string]string
+	CategoryColors    map[string]string
+	Categories        map[string]string
+	AllDayEvents      bool
+	EventDuration     time.Duration
+	UseRecurrence     bool
+	GroupByDay        bool
+	Transparent       bool
+	EventLocation     bool
+	EventStatus       bool
+	Alarms            []string
+	AlarmAction       string
+	AlarmEmail        string
+	UIDIncludeUPRN    bool
+	ServeStaleOnError bool
+	ScrapeOnce        bool
+	AuthToken         string
+	TrustProxy        bool
+	AllowedIPs        []netip.Prefix
+	AllowedOrigins    []string
+	Debug             bool
+	LogLevel          slog.Level
+	LogFormat         string
+}
+
+// Load builds the Config using environment variables and, when CONFIG_FILE
+// names one, a settings file. The environment overrides the file.
 func Load() (Config, error) {
-	cacheTTL, err := readDuration("CACHE_TTL", defaultCacheTTL)
+	lookup := os.Getenv
+	var settings map[string]string
+	if path := strings.TrimSpace(os.Getenv("CONFIG_FILE")); path != "" {
+		var err error
+		if settings, err = readConfigFile(path); err != nil {
+			return Config{}, err
+		}
+		lookup = fileLookup(settings)
+	}
+
+	// The keys load asks for are the settings a file may contain.
+	known := make(map[string]bool)
+	cfg, err := load(func(key string) string {
+		known[key] = true
+		return lookup(key)
+	})
+	if err != nil {
+		return Config{}, err
+	}
+	if unknown := unknownSettings(settings, known); len(unknown) > 0 {
+		return Config{}, fmt.Errorf("CONFIG_FILE: unknown settings %s", strings.Join(unknown, ", "))
+	}
+	return cfg, nil
+}
+
+// load builds the Config from the settings lookup returns, "" meaning unset.
+func load(lookup func(string) string) (Config, error) {
+	cacheTTL, err := readDuration(lookup, "CACHE_TTL", defaultCacheTTL)
+	if err != nil {
+		return Config{}, err
+	}
+
+	minFreshness, err := readDuration(lookup, "MIN_FRESHNESS", 0)
+	if err != nil {
+		return Config{}, err
+	}
+
+	refreshInterval, err := readDuration(lookup, "REFRESH_INTERVAL", 0)
+	if err != nil {
+		return Config{}, err
+	}
+	if refreshInterval < 0 {
+		return Config{}, fmt.Errorf("REFRESH_INTERVAL must not be negative")
+	}
+
+	horizon, err := readHorizon(lookup, "HORIZON")
+	if err != nil {
+		return Config{}, err
+	}
+
+	timeout, err := readDuration(lookup, "SCRAPE_TIMEOUT", defaultRequestTimout)
+	if err != nil {
+		return Config{}, err
+	}
+
+	maxRetries, err := readInt(lookup, "SCRAPE_RETRIES", defaultMaxRetries)
+	if err != nil {
+		return Config{}, err
+	}
+	if maxRetries < 0 {
+		return Config{}, fmt.Errorf("SCRAPE_RETRIES must not be negative")
+	}
+
+	retryDelay, err := readDuration(lookup, "SCRAPE_RETRY_DELAY", defaultRetryDelay)
+	if err != nil {
+		return Config{}, err
+	}
+
+	requestDelay, err := readDuration(lookup, "SCRAPE_REQUEST_DELAY", defaultRequestDelay)
+	if err != nil {
+		return Config{}, err
+	}
+
+	maxBodyBytes, err := readInt(lookup, "SCRAPE_MAX_BODY_BYTES", defaultMaxBodyBytes)
+	if err != nil {
+		return Config{}, err
+	}
+	if maxBodyBytes < 1 {
+		return Config{}, fmt.Errorf("SCRAPE_MAX_BODY_BYTES must be positive")
+	}
+
+	maxIdleConns, err := readInt(lookup, "SCRAPE_MAX_IDLE_CONNS", defaultMaxIdle)
+	if err != nil {
+		return Config{}, err
+	}
+	maxIdlePerHost, err := readInt(lookup, "SCRAPE_MAX_IDLE_CONNS_PER_HOST", defaultMaxIdlePer)
+	if err != nil {
+		return Config{}, err
+	}
+	idleConnTimeout, err := readDuration(lookup, "SCRAPE_IDLE_CONN_TIMEOUT", defaultIdleTimeout)
+	if err != nil {
+		return Config{}, err
+	}
+	if maxIdleConns < 1 || maxIdlePerHost < 1 || idleConnTimeout <= 0 {
+		return Config{}, fmt.Errorf("SCRAPE_MAX_IDLE_CONNS, SCRAPE_MAX_IDLE_CONNS_PER_HOST and SCRAPE_IDLE_CONN_TIMEOUT must be positive")
+	}
+
+	concurrency, err := readInt(lookup, "SCRAPE_CONCURRENCY", defaultConcurrency)
+	if err != nil {
+		return Config{}, err
+	}
+	if concurrency < 1 {
+		return Config{}, fmt.Errorf("SCRAPE_CONCURRENCY must be at least 1")
+	}
+
+	scrapeDays, err := readWeekdays(lookup, "SCRAPE_DAYS")
+	if err != nil {
+		return Config{}, err
+	}
+
+	streamInterval, err := readDuration(lookup, "STREAM_INTERVAL", defaultStreamTick)
+	if err != nil {
+		return Config{}, err
+	}
+	if streamInterval <= 0 {
+		return Config{}, fmt.Errorf("STREAM_INTERVAL must be positive")
+	}
+
+	breakerThreshold, err := readInt(lookup, "BREAKER_THRESHOLD", defaultBreakerFails)
+	if err != nil {
+		return Config{}, err
+	}
+	breakerCooldown, err := readDuration(lookup, "BREAKER_COOLDOWN", defaultBreakerCool)
+	if err != nil {
+		return Config{}, err
+	}
+	if breakerThreshold > 0 && breakerCooldown <= 0 {
+		return Config{}, fmt.Errorf("BREAKER_COOLDOWN must be positive")
+	}
+
+	failureWebhook := strings.TrimSpace(lookup("FAILURE_WEBHOOK_URL"))
+	if failureWebhook != "" {
+		u, err := url.Parse(failureWebhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return Config{}, fmt.Errorf("invalid FAILURE_WEBHOOK_URL %q: want an http(s) URL", failureWebhook)
+		}
+	}
+	webhookThreshold, err := readInt(lookup, "FAILURE_WEBHOOK_THRESHOLD", defaultWebhookFails)
+	if err != nil {
+		return Config{}, err
+	}
+	if webhookThreshold <= 0 {
+		return Config{}, fmt.Errorf("FAILURE_WEBHOOK_THRESHOLD must be positive")
+	}
+	webhookTimeout, err := readDuration(lookup, "FAILURE_WEBHOOK_TIMEOUT", defaultWebhookWait)
+	if err != nil {
+		return Config{}, err
+	}
+	if webhookTimeout <= 0 {
+		return Config{}, fmt.Errorf("FAILURE_WEBHOOK_TIMEOUT must be positive")
+	}
+
+	todayWindow, err := readDuration(lookup, "TODAY_WINDOW", defaultTodayWindow)
+	if err != nil {
+		return Config{}, err
+	}
+	if todayWindow <= 0 {
+		return Config{}, fmt.Errorf("TODAY_WINDOW must be positive")
+	}
+
+	allDay, err := readBool(lookup, "ALL_DAY_EVENTS", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	recurrence, err := readBool(lookup, "USE_RECURRENCE", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	groupByDay, err := readBool(lookup, "GROUP_BY_DAY", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	transparent, err := readBool(lookup, "EVENT_TRANSPARENT", true)
+	if err != nil {
+		return Config{}, err
+	}
+
+	eventLocation, err := readBool(lookup, "EVENT_LOCATION", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	eventStatus, err := readBool(lookup, "EVENT_STATUS", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	// Triggers are validated by the calendar builder; they are only
+	// uppercased here so "-pt2h" reads the same as "-PT2H".
+	var alarms []string
+	for _, trigger := range readList(lookup, "ALARMS") {
+		alarms = append(alarms, strings.ToUpper(trigger))
+	}
+
+	alarmAction := strings.ToLower(strings.TrimSpace(getEnv(lookup, "ALARM_ACTION", "display")))
+	switch alarmAction {
+	case "display", "audio":
+	case "email":
+		if strings.TrimSpace(lookup("ALARM_EMAIL")) == "" {
+			return Config{}, fmt.Errorf("ALARM_EMAIL is required when ALARM_ACTION is email")
+		}
+	default:
+		return Config{}, fmt.Errorf("invalid ALARM_ACTION %q: want display, audio or email", alarmAction)
+	}
+
+	uidIncludeUPRN, err := readBool(lookup, "UID_INCLUDE_UPRN", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	maxEvents, err := readInt(lookup, "MAX_EVENTS", 0)
+	if err != nil {
+		return Config{}, err
+	}
+	if maxEvents < 0 {
+		return Config{}, fmt.Errorf("MAX_EVENTS must not be negative")
+	}
+
+	eventDuration, err := readDuration(lookup, "EVENT_DURATION", defaultEventDuration)
+	if err != nil {
+		return Config{}, err
+	}
+	if eventDuration <= 0 {
+		return Config{}, fmt.Errorf("EVENT_DURATION must be positive")
+	}
+
+	serveStale, err := readBool(lookup, "SERVE_STALE_ON_ERROR", true)
+	if err != nil {
+		return Config{}, err
+	}
+
+	debug, err := readBool(lookup, "DEBUG", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	trustProxy, err := readBool(lookup, "TRUST_PROXY", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	allowedIPs, err := readPrefixes(lookup, "ALLOWED_IPS")
 	if err != nil {
 		return Config{}, err
 	}
 
-	timeout, err := readDuration("SCRAPE_TIMEOUT", defaultRequestTimout)
+	postcode, err := readPostcode(lookup, "POSTCODE")
 	if err != nil {
 		return Config{}, err
 	}
 
-	startHour, err := readInt("START_HOUR", defaultStartHour)
+	latitude, err := readCoordinate(lookup, "LATITUDE", 90)
+	if err != nil {
+		return Config{}, err
+	}
+	longitude, err := readCoordinate(lookup, "LONGITUDE", 180)
+	if err != nil {
+		return Config{}, err
+	}
+
+	logLevel, err := readLogLevel(lookup, "LOG_LEVEL")
+	if err != nil {
+		return Config{}, err
+	}
+
+	logFormat := strings.ToLower(strings.TrimSpace(getEnv(lookup, "LOG_FORMAT", "json")))
+	if logFormat != "json" && logFormat != "text" {
+		return Config{}, fmt.Errorf("invalid LOG_FORMAT %q: want text or json", logFormat)
+	}
+
+	saveAddressMethod := strings.ToUpper(strings.TrimSpace(getEnv(lookup, "SAVE_ADDRESS_METHOD", "GET")))
+	if saveAddressMethod != "GET" && saveAddressMethod != "POST" {
+		return Config{}, fmt.Errorf("invalid SAVE_ADDRESS_METHOD %q: want GET or POST", saveAddressMethod)
+	}
+
+	scrapeOnce, err := readBool(lookup, "SCRAPE_ONCE", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	translations, err := readMap(lookup, "TYPE_TRANSLATIONS")
+	if err != nil {
+		return Config{}, err
+	}
+
+	aliases, err := readMap(lookup, "TYPE_ALIASES")
+	if err != nil {
+		return Config{}, err
+	}
+
+	colors, err := readMap(lookup, "CATEGORY_COLORS")
+	if err != nil {
+		return Config{}, err
+	}
+
+	categories, err := readMap(lookup, "TYPE_CATEGORIES")
+	if err != nil {
+		return Config{}, err
+	}
+
+	startHour, err := readInt(lookup, "START_HOUR", defaultStartHour)
 	if err != nil {
 		return Config{}, err
 	}
@@ -61,40 +432,257 @@ func Load() (Config, error) {
 		return Config{}, fmt.Errorf("START_HOUR must be between 0 and 23")
 	}
 
+	windowEndHour, err := readInt(lookup, "COLLECTION_WINDOW_END", 0)
+	if err != nil {
+		return Config{}, err
+	}
+	if windowEndHour != 0 && (windowEndHour <= startHour || windowEndHour > 24) {
+		return Config{}, fmt.Errorf("COLLECTION_WINDOW_END must be after START_HOUR and at most 24")
+	}
+
+	startHours, err := readMap(lookup, "START_HOUR_BY_TYPE")
+	if err != nil {
+		return Config{}, err
+	}
+	var startHourByType map[string]int
+	for wasteType, raw := range startHours {
+		hour, err := strconv.Atoi(raw)
+		if err != nil || hour < 0 || hour > 23 {
+			return Config{}, fmt.Errorf("START_HOUR_BY_TYPE hour for %q must be between 0 and 23", wasteType)
+		}
+		if startHourByType == nil {
+			startHourByType = make(map[string]int, len(startHours))
+		}
+		startHourByType[wasteType] = hour
+	}
+
 	cfg := Config{
-		ListenAddr:     getEnv("LISTEN_ADDR", defaultListenAddr),
-		BaseURL:        strings.TrimRight(getEnv("BASE_URL", defaultBaseURL), "/"),
-		SchedulePath:   ensurePath(getEnv("SCHEDULE_PATH", defaultSchedulePath)),
-		UPRN:           os.Getenv("UPRN"),
-		AddressLine:    os.Getenv("ADDRESS_LINE"),
-		Postcode:       os.Getenv("POSTCODE"),
-		Latitude:       os.Getenv("LATITUDE"),
-		Longitude:      os.Getenv("LONGITUDE"),
-		CacheTTL:       cacheTTL,
-		StartHour:      startHour,
-		UserAgent:      getEnv("USER_AGENT", defaultUserAgent),
-		RequestTimeout: timeout,
-		Timezone:       londonTimezone,
-		CalendarName:   calendarName,
-		CalendarDesc:   calendarDescription,
-	}
-
-	if cfg.UPRN == "" {
+		ListenAddr:        getEnv(lookup, "LISTEN_ADDR", defaultListenAddr),
+		BaseURL:           strings.TrimRight(getEnv(lookup, "BASE_URL", defaultBaseURL), "/"),
+		SchedulePath:      ensurePath(getEnv(lookup, "SCHEDULE_PATH", defaultSchedulePath)),
+		UPRNs:             uniqueList(readList(lookup, "UPRN")),
+		AddressLine:       lookup("ADDRESS_LINE"),
+		Postcode:          postcode,
+		Latitude:          latitude,
+		Longitude:         longitude,
+		CacheTTL:          cacheTTL,
+		CacheFile:         lookup("CACHE_FILE"),
+		MinFreshness:      minFreshness,
+		RefreshInterval:   refreshInterval,
+		Horizon:           horizon,
+		StartHour:         startHour,
+		StartHourByType:   startHourByType,
+		WindowEndHour:     windowEndHour,
+		UserAgent:         getEnv(lookup, "USER_AGENT", defaultUserAgent),
+		UserAgents:        readList(lookup, "USER_AGENTS"),
+		ProxyURL:          strings.TrimSpace(lookup("PROXY_URL")),
+		OriginUsername:    lookup("ORIGIN_USERNAME"),
+		OriginPassword:    lookup("ORIGIN_PASSWORD"),
+		SaveAddressMethod: saveAddressMethod,
+		RequestTimeout:    timeout,
+		MaxRetries:        maxRetries,
+		RetryBaseDelay:    retryDelay,
+		MaxBodyBytes:      int64(maxBodyBytes),
+		MaxIdleConns:      maxIdleConns,
+		MaxIdlePerHost:    maxIdlePerHost,
+		IdleConnTimeout:   idleConnTimeout,
+		RequestDelay:      requestDelay,
+		ScrapeConcurrency: concurrency,
+		ScrapeDays:        scrapeDays,
+		StreamInterval:    streamInterval,
+		TodayWindow:       todayWindow,
+		BreakerThreshold:  breakerThreshold,
+		BreakerCooldown:   breakerCooldown,
+		FailureWebhook:    failureWebhook,
+		WebhookThreshold:  webhookThreshold,
+		WebhookTimeout:    webhookTimeout,
+		Timezone:          londonTimezone,
+		CalendarName:      calendarName,
+		CalendarDesc:      calendarDescription,
+		CalendarTypes:     readList(lookup, "ICS_TYPES"),
+		MaxEvents:         maxEvents,
+		SummaryTemplate:   lookup("SUMMARY_TEMPLATE"),
+		EventDescription:  lookup("EVENT_DESCRIPTION"),
+		TypeTranslations:  translations,
+		TypeAliases:       aliases,
+		CategoryColors:    colors,
+		Categories:        categories,
+		AllDayEvents:      allDay,
+		EventDuration:     eventDuration,
+		UseRecurrence:     recurrence,
+		GroupByDay:        groupByDay,
+		Transparent:       transparent,
+		EventLocation:     eventLocation,
+		EventStatus:       eventStatus,
+		Alarms:            alarms,
+		AlarmAction:       alarmAction,
+		AlarmEmail:        strings.TrimSpace(lookup("ALARM_EMAIL")),
+		UIDIncludeUPRN:    uidIncludeUPRN,
+		ServeStaleOnError: serveStale,
+		ScrapeOnce:        scrapeOnce,
+		AuthToken:         lookup("AUTH_TOKEN"),
+		TrustProxy:        trustProxy,
+		AllowedIPs:        allowedIPs,
+		AllowedOrigins:    readList(lookup, "ALLOWED_ORIGINS"),
+		Debug:             debug,
+		LogLevel:          logLevel,
+		LogFormat:         logFormat,
+	}
+
+	if len(cfg.UPRNs) == 0 {
 		return Config{}, errors.New("UPRN is required")
 	}
+	cfg.UPRN = cfg.UPRNs[0]
 
 	return cfg, nil
 }
 
-func getEnv(key, fallback string) string {
-	if val := os.Getenv(key); val != "" {
+func getEnv(lookup func(string) string, key, fallback string) string {
+	if val := lookup(key); val != "" {
 		return val
 	}
 	return fallback
 }
 
-func readDuration(key string, fallback time.Duration) (time.Duration, error) {
-	val := os.Getenv(key)
+// readWeekdays parses a comma-separated list of weekday names, full or
+// abbreviated to three letters, in any case.
+func readWeekdays(lookup func(string) string, key string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, entry := range readList(lookup, key) {
+		name := strings.ToLower(entry)
+		found := false
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			full := strings.ToLower(d.String())
+			if name == full || name == full[:3] {
+				days = append(days, d)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("invalid weekday %q in %s", entry, key)
+		}
+	}
+	return days, nil
+}
+
+// readPrefixes parses a comma-separated list of CIDR ranges; bare addresses
+// are treated as single-host ranges.
+func readPrefixes(lookup func(string) string, key string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range readList(lookup, key) {
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid address %q in %s: %w", entry, key, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q in %s: %w", entry, key, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// readPostcode normalizes the postcode the same way the address search does,
+// so "ig11aa" and " IG1  1AA" both become "IG1 1AA". An empty value is left
+// empty.
+func readPostcode(lookup func(string) string, key string) (string, error) {
+	raw := lookup(key)
+	if strings.TrimSpace(raw) == "" {
+		return "", nil
+	}
+	postcode, err := scraper.NormalizePostcode(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s %q", key, raw)
+	}
+	return postcode, nil
+}
+
+// readCoordinate reads an optional decimal-degree coordinate, rejecting
+// values that don't parse (such as "51,5") or fall outside ±limit. The
+// trimmed text is returned as given, since it is passed on verbatim.
+func readCoordinate(lookup func(string) string, key string, limit float64) (string, error) {
+	raw := strings.TrimSpace(lookup(key))
+	if raw == "" {
+		return "", nil
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s %q: want decimal degrees such as 51.5", key, raw)
+	}
+	// Written so NaN fails too.
+	if !(math.Abs(value) <= limit) {
+		return "", fmt.Errorf("invalid %s %q: must be between -%g and %g", key, raw, limit, limit)
+	}
+	return raw, nil
+}
+
+func readLogLevel(lookup func(string) string, key string) (slog.Level, error) {
+	switch val := strings.ToLower(strings.TrimSpace(lookup(key))); val {
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("invalid %s %q: want debug, info, warn or error", key, val)
+	}
+}
+
+func readList(lookup func(string) string, key string) []string {
+	var values []string
+	for _, part := range strings.Split(lookup(key), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		values = append(values, part)
+	}
+	return values
+}
+
+// readMap parses comma-separated key=value pairs.
+func readMap(lookup func(string) string, key string) (map[string]string, error) {
+	pairs := readList(lookup, key)
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+
+	values := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		k, v, ok := strings.Cut(pair, "=")
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if !ok || k == "" || v == "" {
+			return nil, fmt.Errorf("invalid key=value pair %q for %s", pair, key)
+		}
+		values[k] = v
+	}
+	return values, nil
+}
+
+func uniqueList(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	var unique []string
+	for _, v := range values {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		unique = append(unique, v)
+	}
+	return unique
+}
+
+func readDuration(lookup func(string) string, key string, fallback time.Duration) (time.Duration, error) {
+	val := lookup(key)
 	if val == "" {
 		return fallback, nil
 	}
@@ -107,8 +695,33 @@ func readDuration(key string, fallback time.Duration) (time.Duration, error) {
 	return d, nil
 }
 
-func readInt(key string, fallback int) (int, error) {
-	val := os.Getenv(key)
+// readHorizon accepts either a Go duration or a bare number of weeks. Zero,
+// the default, disables the horizon.
+func readHorizon(lookup func(string) string, key string) (time.Duration, error) {
+	val := strings.TrimSpace(lookup(key))
+	if val == "" {
+		return 0, nil
+	}
+
+	if weeks, err := strconv.Atoi(val); err == nil {
+		if weeks < 0 {
+			return 0, fmt.Errorf("%s must not be negative", key)
+		}
+		return time.Duration(weeks) * 7 * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid horizon for %s: want a duration or a number of weeks", key)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("%s must not be negative", key)
+	}
+	return d, nil
+}
+
+func readInt(lookup func(string) string, key string, fallback int) (int, error) {
+	val := lookup(key)
 	if val == "" {
 		return fallback, nil
 	}
@@ -121,6 +734,20 @@ func readInt(key string, fallback int) (int, error) {
 	return i, nil
 }
 
+func readBool(lookup func(string) string, key string, fallback bool) (bool, error) {
+	val := lookup(key)
+	if val == "" {
+		return fallback, nil
+	}
+
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, fmt.Errorf("invalid boolean for %s: %w", key, err)
+	}
+
+	return b, nil
+}
+
 func ensurePath(p string) string {
 	if p == "" {
 		return ""
@@ -1,6 +1,12 @@
 package config
 
-import "testing"
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
 
 func TestLoadConfigDefaults(t *testing.T) {
 	t.Setenv("UPRN", "123")
@@ -15,6 +21,21 @@ func TestLoadConfigDefaults(t *testing.T) {
 	if cfg.CacheTTL.Hours() != 168 {
 		t.Fatalf("expected cache ttl 168h, got %s", cfg.CacheTTL)
 	}
+	if !cfg.ServeStaleOnError {
+		t.Fatalf("expected stale serving enabled by default")
+	}
+	if cfg.MaxRetries != 2 {
+		t.Fatalf("expected default retries 2, got %d", cfg.MaxRetries)
+	}
+	if cfg.ScrapeConcurrency != 2 {
+		t.Fatalf("expected default scrape concurrency 2, got %d", cfg.ScrapeConcurrency)
+	}
+	if cfg.RequestDelay != 150*time.Millisecond {
+		t.Fatalf("expected default request delay 150ms, got %s", cfg.RequestDelay)
+	}
+	if !cfg.Transparent {
+		t.Fatalf("expected transparent events by default")
+	}
 	if cfg.CalendarName == "" || cfg.CalendarDesc == "" {
 		t.Fatalf("calendar metadata missing")
 	}
@@ -27,7 +48,19 @@ func TestLoadConfigOverrides(t *testing.T) {
 	t.Setenv("SCHEDULE_PATH", "custom")
 	t.Setenv("CACHE_TTL", "24h")
 	t.Setenv("START_HOUR", "7")
+	t.Setenv("START_HOUR_BY_TYPE", "Food Waste=5")
 	t.Setenv("SCRAPE_TIMEOUT", "5s")
+	t.Setenv("USER_AGENTS", "agent-a, agent-b,")
+	t.Setenv("ICS_TYPES", "Refuse, Food Waste")
+	t.Setenv("TYPE_TRANSLATIONS", "Refuse=Restmüll, Recycling=Wertstoffe")
+	t.Setenv("TYPE_ALIASES", "Blue Bin=Recycling")
+	t.Setenv("CATEGORY_COLORS", "Refuse=black,Recycling=blue")
+	t.Setenv("ALL_DAY_EVENTS", "true")
+	t.Setenv("EVENT_DURATION", "2h")
+	t.Setenv("SCRAPE_RETRIES", "4")
+	t.Setenv("SCRAPE_RETRY_DELAY", "1s")
+	t.Setenv("HORIZON", "3")
+	t.Setenv("REFRESH_INTERVAL", "6h")
 
 	cfg, err := Load()
 	if err != nil {
@@ -49,9 +82,436 @@ func TestLoadConfigOverrides(t *testing.T) {
 	if cfg.StartHour != 7 {
 		t.Fatalf("StartHour override failed: %d", cfg.StartHour)
 	}
+	if cfg.StartHourByType["Food Waste"] != 5 {
+		t.Fatalf("StartHourByType parsing failed: %v", cfg.StartHourByType)
+	}
 	if cfg.RequestTimeout.String() != "5s" {
 		t.Fatalf("RequestTimeout override failed: %s", cfg.RequestTimeout)
 	}
+	if len(cfg.UserAgents) != 2 || cfg.UserAgents[0] != "agent-a" || cfg.UserAgents[1] != "agent-b" {
+		t.Fatalf("UserAgents parsing failed: %v", cfg.UserAgents)
+	}
+	if len(cfg.CalendarTypes) != 2 || cfg.CalendarTypes[0] != "Refuse" || cfg.CalendarTypes[1] != "Food Waste" {
+		t.Fatalf("CalendarTypes parsing failed: %v", cfg.CalendarTypes)
+	}
+	if cfg.TypeAliases["Blue Bin"] != "Recycling" {
+		t.Fatalf("TypeAliases parsing failed: %v", cfg.TypeAliases)
+	}
+	if cfg.TypeTranslations["Refuse"] != "Restmüll" || cfg.TypeTranslations["Recycling"] != "Wertstoffe" {
+		t.Fatalf("TypeTranslations parsing failed: %v", cfg.TypeTranslations)
+	}
+	if cfg.CategoryColors["Refuse"] != "black" || cfg.CategoryColors["Recycling"] != "blue" {
+		t.Fatalf("CategoryColors parsing failed: %v", cfg.CategoryColors)
+	}
+	if !cfg.AllDayEvents || cfg.EventDuration != 2*time.Hour {
+		t.Fatalf("event options override failed: %v %s", cfg.AllDayEvents, cfg.EventDuration)
+	}
+	if cfg.MaxRetries != 4 {
+		t.Fatalf("MaxRetries override failed: %d", cfg.MaxRetries)
+	}
+	if cfg.RetryBaseDelay != time.Second {
+		t.Fatalf("RetryBaseDelay override failed: %s", cfg.RetryBaseDelay)
+	}
+	if cfg.RefreshInterval != 6*time.Hour {
+		t.Fatalf("RefreshInterval override failed: %s", cfg.RefreshInterval)
+	}
+	if cfg.Horizon != 21*24*time.Hour {
+		t.Fatalf("Horizon week count parsing failed: %s", cfg.Horizon)
+	}
+}
+
+func TestLoadConfigHorizonDuration(t *testing.T) {
+	t.Setenv("UPRN", "123")
+	t.Setenv("HORIZON", "240h")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Horizon != 240*time.Hour {
+		t.Fatalf("Horizon duration parsing failed: %s", cfg.Horizon)
+	}
+
+	t.Setenv("HORIZON", "soon")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for malformed HORIZON")
+	}
+}
+
+func TestLoadConfigMultipleUPRNs(t *testing.T) {
+	t.Setenv("UPRN", "123, 456,,123")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if len(cfg.UPRNs) != 2 || cfg.UPRNs[0] != "123" || cfg.UPRNs[1] != "456" {
+		t.Fatalf("unexpected UPRNs %v", cfg.UPRNs)
+	}
+	if cfg.UPRN != "123" {
+		t.Fatalf("expected primary UPRN 123, got %s", cfg.UPRN)
+	}
+}
+
+func TestLoadConfigLogging(t *testing.T) {
+	t.Setenv("UPRN", "123")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.LogLevel != slog.LevelInfo || cfg.LogFormat != "json" {
+		t.Fatalf("unexpected logging defaults %s %s", cfg.LogLevel, cfg.LogFormat)
+	}
+
+	t.Setenv("LOG_LEVEL", "DEBUG")
+	t.Setenv("LOG_FORMAT", "text")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.LogLevel != slog.LevelDebug || cfg.LogFormat != "text" {
+		t.Fatalf("logging override failed: %s %s", cfg.LogLevel, cfg.LogFormat)
+	}
+
+	t.Setenv("LOG_LEVEL", "verbose")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for unknown LOG_LEVEL")
+	}
+
+	t.Setenv("LOG_LEVEL", "warn")
+	t.Setenv("LOG_FORMAT", "xml")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for unknown LOG_FORMAT")
+	}
+}
+
+func TestLoadConfigScrapeDays(t *testing.T) {
+	t.Setenv("UPRN", "123")
+	t.Setenv("SCRAPE_DAYS", "Monday, tue,WEDNESDAY")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday}
+	if len(cfg.ScrapeDays) != len(want) {
+		t.Fatalf("unexpected ScrapeDays %v", cfg.ScrapeDays)
+	}
+	for i, day := range want {
+		if cfg.ScrapeDays[i] != day {
+			t.Fatalf("ScrapeDays[%d] = %s, want %s", i, cfg.ScrapeDays[i], day)
+		}
+	}
+
+	t.Setenv("SCRAPE_DAYS", "Mon,Funday")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for unknown weekday")
+	}
+}
+
+func TestLoadConfigAllowedIPs(t *testing.T) {
+	t.Setenv("UPRN", "123")
+	t.Setenv("TRUST_PROXY", "true")
+	t.Setenv("ALLOWED_IPS", "192.168.1.0/24, 10.0.0.7, 2001:db8::/32")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !cfg.TrustProxy {
+		t.Fatalf("expected TrustProxy enabled")
+	}
+	want := []string{"192.168.1.0/24", "10.0.0.7/32", "2001:db8::/32"}
+	if len(cfg.AllowedIPs) != len(want) {
+		t.Fatalf("unexpected AllowedIPs %v", cfg.AllowedIPs)
+	}
+	for i, prefix := range cfg.AllowedIPs {
+		if prefix.String() != want[i] {
+			t.Fatalf("AllowedIPs[%d] = %s, want %s", i, prefix, want[i])
+		}
+	}
+
+	t.Setenv("ALLOWED_IPS", "192.168.1.0/33")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for invalid ALLOWED_IPS")
+	}
+}
+
+func TestLoadConfigPostcode(t *testing.T) {
+	t.Setenv("UPRN", "123")
+	cases := map[string]string{
+		"":          "",
+		"IG1 1AA":   "IG1 1AA",
+		"ig11aa":    "IG1 1AA",
+		"IG1 1AA ":  "IG1 1AA",
+		" ig1  1aa": "IG1 1AA",
+		"e11 1aa":   "E11 1AA",
+		"Rm61Aa":    "RM6 1AA",
+		"ec1a1bb":   "EC1A 1BB",
+		"w1a 0ax":   "W1A 0AX",
+		"m11ae":     "M1 1AE",
+		"gir0aa":    "GIR 0AA",
+	}
+	for input, want := range cases {
+		t.Setenv("POSTCODE", input)
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", input, err)
+		}
+		if cfg.Postcode != want {
+			t.Fatalf("%q: Postcode = %q, want %q", input, cfg.Postcode, want)
+		}
+	}
+
+	for _, input := range []string{"IG1", "not a postcode", "1G1 1AA", "IG1 AAA", "IG12345AA"} {
+		t.Setenv("POSTCODE", input)
+		if _, err := Load(); err == nil {
+			t.Fatalf("%q: expected error for malformed POSTCODE", input)
+		}
+	}
+}
+
+func TestLoadConfigAlarmAction(t *testing.T) {
+	t.Setenv("UPRN", "123")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.AlarmAction != "display" {
+		t.Fatalf("AlarmAction = %q, want display", cfg.AlarmAction)
+	}
+
+	t.Setenv("ALARM_ACTION", " EMAIL ")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for email action without ALARM_EMAIL")
+	}
+	t.Setenv("ALARM_EMAIL", "bins@example.com")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.AlarmAction != "email" || cfg.AlarmEmail != "bins@example.com" {
+		t.Fatalf("alarm = %q/%q, want email/bins@example.com", cfg.AlarmAction, cfg.AlarmEmail)
+	}
+
+	t.Setenv("ALARM_ACTION", "beep")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for unknown ALARM_ACTION")
+	}
+}
+
+func TestLoadConfigAlarms(t *testing.T) {
+	t.Setenv("UPRN", "123")
+	t.Setenv("ALARMS", "")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Alarms != nil {
+		t.Fatalf("Alarms = %v, want nil for the calendar defaults", cfg.Alarms)
+	}
+
+	t.Setenv("ALARMS", " -pt2h, -P1D ,")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Alarms) != 2 || cfg.Alarms[0] != "-PT2H" || cfg.Alarms[1] != "-P1D" {
+		t.Fatalf("Alarms = %v, want [-PT2H -P1D]", cfg.Alarms)
+	}
+}
+
+func TestLoadConfigFailureWebhook(t *testing.T) {
+	t.Setenv("UPRN", "123")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.FailureWebhook != "" || cfg.WebhookThreshold != 3 || cfg.WebhookTimeout != 10*time.Second {
+		t.Fatalf("unexpected defaults %q/%d/%s", cfg.FailureWebhook, cfg.WebhookThreshold, cfg.WebhookTimeout)
+	}
+
+	t.Setenv("FAILURE_WEBHOOK_URL", "https://hooks.example.com/bins")
+	t.Setenv("FAILURE_WEBHOOK_THRESHOLD", "5")
+	t.Setenv("FAILURE_WEBHOOK_TIMEOUT", "3s")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.FailureWebhook != "https://hooks.example.com/bins" || cfg.WebhookThreshold != 5 || cfg.WebhookTimeout != 3*time.Second {
+		t.Fatalf("unexpected webhook config %q/%d/%s", cfg.FailureWebhook, cfg.WebhookThreshold, cfg.WebhookTimeout)
+	}
+
+	for key, value := range map[string]string{
+		"FAILURE_WEBHOOK_URL":       "hooks.example.com/bins",
+		"FAILURE_WEBHOOK_THRESHOLD": "0",
+		"FAILURE_WEBHOOK_TIMEOUT":   "0s",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, value)
+			if _, err := Load(); err == nil {
+				t.Fatalf("expected error for %s=%q", key, value)
+			}
+		})
+	}
+}
+
+func TestLoadConfigCollectionWindowEnd(t *testing.T) {
+	t.Setenv("UPRN", "123")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.WindowEndHour != 0 {
+		t.Fatalf("WindowEndHour = %d, want 0", cfg.WindowEndHour)
+	}
+
+	t.Setenv("COLLECTION_WINDOW_END", "16")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.WindowEndHour != 16 {
+		t.Fatalf("WindowEndHour = %d, want 16", cfg.WindowEndHour)
+	}
+
+	for _, value := range []string{"6", "5", "25", "-1"} {
+		t.Setenv("COLLECTION_WINDOW_END", value)
+		if _, err := Load(); err == nil {
+			t.Fatalf("%q: expected error with START_HOUR 6", value)
+		}
+	}
+}
+
+func TestLoadConfigCoordinates(t *testing.T) {
+	t.Setenv("UPRN", "123")
+	t.Setenv("LATITUDE", " 51.5590 ")
+	t.Setenv("LONGITUDE", "-0.0742")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Latitude != "51.5590" || cfg.Longitude != "-0.0742" {
+		t.Fatalf("coordinates = %q/%q, want 51.5590/-0.0742", cfg.Latitude, cfg.Longitude)
+	}
+
+	t.Setenv("LATITUDE", "")
+	t.Setenv("LONGITUDE", "")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("unexpected error without coordinates: %v", err)
+	}
+	if cfg.Latitude != "" || cfg.Longitude != "" {
+		t.Fatalf("coordinates = %q/%q, want empty", cfg.Latitude, cfg.Longitude)
+	}
+
+	for _, tc := range []struct{ key, value string }{
+		{"LATITUDE", "91"},
+		{"LATITUDE", "-90.5"},
+		{"LONGITUDE", "-181"},
+		{"LATITUDE", "51,5"},
+		{"LONGITUDE", "abc"},
+		{"LATITUDE", "NaN"},
+		{"LONGITUDE", "Inf"},
+	} {
+		t.Setenv("LATITUDE", "")
+		t.Setenv("LONGITUDE", "")
+		t.Setenv(tc.key, tc.value)
+		if _, err := Load(); err == nil {
+			t.Fatalf("%s=%q: expected error", tc.key, tc.value)
+		}
+	}
+}
+
+func TestLoadConfigTodayWindow(t *testing.T) {
+	t.Setenv("UPRN", "123")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.TodayWindow != time.Hour {
+		t.Fatalf("expected default TodayWindow of 1h, got %s", cfg.TodayWindow)
+	}
+
+	t.Setenv("TODAY_WINDOW", "3h")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.TodayWindow != 3*time.Hour {
+		t.Fatalf("expected TodayWindow of 3h, got %s", cfg.TodayWindow)
+	}
+
+	t.Setenv("TODAY_WINDOW", "0s")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for non-positive TODAY_WINDOW")
+	}
+}
+
+func TestLoadConfigBreaker(t *testing.T) {
+	t.Setenv("UPRN", "123")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.BreakerThreshold != 5 || cfg.BreakerCooldown != time.Minute {
+		t.Fatalf("unexpected breaker defaults %d/%s", cfg.BreakerThreshold, cfg.BreakerCooldown)
+	}
+
+	t.Setenv("BREAKER_THRESHOLD", "3")
+	t.Setenv("BREAKER_COOLDOWN", "10m")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.BreakerThreshold != 3 || cfg.BreakerCooldown != 10*time.Minute {
+		t.Fatalf("unexpected breaker settings %d/%s", cfg.BreakerThreshold, cfg.BreakerCooldown)
+	}
+
+	t.Setenv("BREAKER_COOLDOWN", "0s")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for zero BREAKER_COOLDOWN")
+	}
+}
+
+func TestLoadConfigConnectionPool(t *testing.T) {
+	t.Setenv("UPRN", "123")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.MaxIdleConns != 100 || cfg.MaxIdlePerHost != 4 || cfg.IdleConnTimeout != 90*time.Second {
+		t.Fatalf("unexpected pool defaults %d/%d/%s", cfg.MaxIdleConns, cfg.MaxIdlePerHost, cfg.IdleConnTimeout)
+	}
+
+	t.Setenv("SCRAPE_MAX_IDLE_CONNS", "20")
+	t.Setenv("SCRAPE_MAX_IDLE_CONNS_PER_HOST", "10")
+	t.Setenv("SCRAPE_IDLE_CONN_TIMEOUT", "30s")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.MaxIdleConns != 20 || cfg.MaxIdlePerHost != 10 || cfg.IdleConnTimeout != 30*time.Second {
+		t.Fatalf("unexpected pool settings %d/%d/%s", cfg.MaxIdleConns, cfg.MaxIdlePerHost, cfg.IdleConnTimeout)
+	}
+
+	t.Setenv("SCRAPE_MAX_IDLE_CONNS_PER_HOST", "0")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for zero SCRAPE_MAX_IDLE_CONNS_PER_HOST")
+	}
+}
+
+func TestLoadConfigInvalidTranslations(t *testing.T) {
+	t.Setenv("UPRN", "123")
+	t.Setenv("TYPE_TRANSLATIONS", "Refuse")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for malformed TYPE_TRANSLATIONS")
+	}
+}
+
+func TestLoadConfigInvalidStartHourByType(t *testing.T) {
+	t.Setenv("UPRN", "123")
+	t.Setenv("START_HOUR_BY_TYPE", "Food Waste=25")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for out-of-range START_HOUR_BY_TYPE")
+	}
 }
 
 func TestLoadConfigRequiresUPRN(t *testing.T) {
@@ -60,3 +520,138 @@ func TestLoadConfigRequiresUPRN(t *testing.T) {
 		t.Fatalf("expected error when UPRN missing")
 	}
 }
+
+// isolateEnv registers keys with t.Setenv so values a CONFIG_FILE exports
+// are undone when the test ends.
+func isolateEnv(t *testing.T, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		t.Setenv(key, "")
+	}
+}
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	isolateEnv(t, "UPRN", "CACHE_TTL", "START_HOUR", "ICS_TYPES", "CATEGORY_COLORS", "DEBUG", "POSTCODE")
+	path := writeConfigFile(t, "config.json", `{
+		"UPRN": "10023770000",
+		"CACHE_TTL": "24h",
+		"START_HOUR": 5,
+		"ICS_TYPES": ["Refuse", "Recycling"],
+		"CATEGORY_COLORS": {"Refuse": "black", "Recycling": "blue"},
+		"debug": true,
+		"POSTCODE": "ig11aa"
+	}`)
+	t.Setenv("CONFIG_FILE", path)
+	// The environment overrides the file.
+	t.Setenv("CACHE_TTL", "2h")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.UPRN != "10023770000" || cfg.StartHour != 5 || !cfg.Debug || cfg.Postcode != "IG1 1AA" {
+		t.Fatalf("file values not applied: %+v", cfg)
+	}
+	if cfg.CacheTTL != 2*time.Hour {
+		t.Fatalf("CacheTTL = %s, want the environment's 2h", cfg.CacheTTL)
+	}
+	if len(cfg.CalendarTypes) != 2 || cfg.CalendarTypes[0] != "Refuse" || cfg.CalendarTypes[1] != "Recycling" {
+		t.Fatalf("unexpected CalendarTypes %v", cfg.CalendarTypes)
+	}
+	if cfg.CategoryColors["Refuse"] != "black" || cfg.CategoryColors["Recycling"] != "blue" {
+		t.Fatalf("unexpected CategoryColors %v", cfg.CategoryColors)
+	}
+}
+
+func TestLoadConfigFileYAML(t *testing.T) {
+	isolateEnv(t, "UPRN", "CACHE_TTL", "ICS_TYPES", "SUMMARY_TEMPLATE", "ADDRESS_LINE", "EVENT_DESCRIPTION", "CATEGORY_COLORS")
+	path := writeConfigFile(t, "config.yaml", `# Redbridge settings
+---
+UPRN: "10023770000"
+CACHE_TTL: 24h # daily
+ICS_TYPES: [Refuse, 'Food Waste']
+SUMMARY_TEMPLATE: "Bin: {{.Type}}"
+ADDRESS_LINE: '1 O''Brien Road'
+EVENT_DESCRIPTION: "Put it out" # say "hi"
+CATEGORY_COLORS:
+  Refuse: black
+  Food Waste: green
+`)
+	t.Setenv("CONFIG_FILE", path)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.UPRN != "10023770000" || cfg.CacheTTL != 24*time.Hour || cfg.SummaryTemplate != "Bin: {{.Type}}" || cfg.AddressLine != "1 O'Brien Road" {
+		t.Fatalf("file values not applied: %+v", cfg)
+	}
+	if cfg.EventDescription != "Put it out" {
+		t.Fatalf("EventDescription = %q, want the quoted value without its comment", cfg.EventDescription)
+	}
+	if len(cfg.CalendarTypes) != 2 || cfg.CalendarTypes[1] != "Food Waste" {
+		t.Fatalf("unexpected CalendarTypes %v", cfg.CalendarTypes)
+	}
+	if cfg.CategoryColors["Refuse"] != "black" || cfg.CategoryColors["Food Waste"] != "green" {
+		t.Fatalf("unexpected CategoryColors %v", cfg.CategoryColors)
+	}
+}
+
+func TestLoadConfigFileLeavesEnvironment(t *testing.T) {
+	isolateEnv(t, "UPRN", "CACHE_TTL")
+	t.Setenv("CONFIG_FILE", writeConfigFile(t, "config.json", `{"UPRN": "123", "CACHE_TTL": "24h"}`))
+
+	for i := 0; i < 2; i++ {
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if cfg.UPRN != "123" || cfg.CacheTTL != 24*time.Hour {
+			t.Fatalf("load %d: file values not applied: %+v", i, cfg)
+		}
+	}
+	if os.Getenv("UPRN") != "" || os.Getenv("CACHE_TTL") != "" {
+		t.Fatalf("Load exported file settings to the environment")
+	}
+}
+
+func TestLoadConfigFileInvalid(t *testing.T) {
+	t.Setenv("UPRN", "123")
+	cases := map[string]string{
+		"unknown.json":   `{"UPRN": "123", "CACHE_TTLL": "1h"}`,
+		"unknown.yaml":   "UPRN: 123\nSTART_HOR: 6\n",
+		"nested.yaml":    "UPRN: 123\nCATEGORY_COLORS:\n  Refuse:\n    colour: black\n",
+		"pair.yaml":      "UPRN: 123\nCATEGORY_COLORS:\n  Refuse: black,blue\n",
+		"pair.json":      `{"UPRN": "123", "CATEGORY_COLORS": {"Refuse": "a=b"}}`,
+		"paircomma.json": `{"UPRN": "123", "CATEGORY_COLORS": {"Refuse,Recycling": "black"}}`,
+		"null.yaml":      "UPRN: 123\nCACHE_FILE:\n",
+		"comma.yaml":     "UPRN: 123\nICS_TYPES: [\"Refuse, Recycling\", Garden]\n",
+		"comma.json":     `{"UPRN": "123", "ICS_TYPES": ["Refuse, Recycling", "Garden"]}`,
+		"duplicate.yaml": "UPRN: 123\nuprn: 456\n",
+		"null.json":      `{"UPRN": null}`,
+		"malformed.json": `{"UPRN": "123"`,
+		"config.toml":    `UPRN = "123"`,
+	}
+	for name, content := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("CONFIG_FILE", writeConfigFile(t, name, content))
+			if _, err := Load(); err == nil {
+				t.Fatalf("expected error for %s", name)
+			}
+		})
+	}
+
+	t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.json"))
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for a missing file")
+	}
+}
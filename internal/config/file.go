@@ -0,0 +1,233 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// readConfigFile reads the settings in path, keyed by the environment
+// variable each one stands in for. Files ending in .json are JSON objects;
+// .yaml and .yml files are YAML mappings.
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read CONFIG_FILE: %w", err)
+	}
+
+	var settings map[string]string
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		settings, err = parseJSONSettings(data)
+	case ".yaml", ".yml":
+		settings, err = parseYAMLSettings(data)
+	default:
+		return nil, fmt.Errorf("CONFIG_FILE %s: unsupported extension %q, want .json, .yaml or .yml", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("CONFIG_FILE %s: %w", path, err)
+	}
+	return settings, nil
+}
+
+// fileLookup reads a setting from the environment, falling back to settings
+// when the variable is unset or empty.
+func fileLookup(settings map[string]string) func(string) string {
+	return func(key string) string {
+		if val := os.Getenv(key); val != "" {
+			return val
+		}
+		return settings[key]
+	}
+}
+
+// unknownSettings lists, sorted, the keys in settings that Load never read.
+func unknownSettings(settings map[string]string, known map[string]bool) []string {
+	var unknown []string
+	for key := range settings {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// addSetting stores value under name's environment variable name, rejecting
+// a second spelling of the same setting.
+func addSetting(settings map[string]string, name, value string) error {
+	key := strings.ToUpper(strings.TrimSpace(name))
+	if _, dup := settings[key]; dup {
+		return fmt.Errorf("duplicate setting %q", name)
+	}
+	settings[key] = value
+	return nil
+}
+
+// errListComma is returned for list items the comma-separated form of the
+// matching environment variable cannot carry.
+var errListComma = errors.New("list items must not contain commas")
+
+// settingPair formats one entry of an object or mapping as the key=value
+// pair the matching environment variable takes.
+func settingPair(key, value string) (string, error) {
+	if strings.Contains(key, ",") || strings.Contains(value, ",") {
+		return "", errListComma
+	}
+	if strings.Contains(key, "=") || strings.Contains(value, "=") {
+		return "", fmt.Errorf("pair %q: keys and values must not contain '='", key)
+	}
+	return key + "=" + value, nil
+}
+
+// parseJSONSettings reads a JSON object of settings. Values may be strings,
+// numbers or booleans; arrays become comma-separated lists and objects
+// key=value pairs, the forms the matching environment variables take.
+func parseJSONSettings(data []byte) (map[string]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw map[string]interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("parse JSON: %w", err)
+	}
+
+	settings := make(map[string]string, len(raw))
+	for name, value := range raw {
+		text, err := jsonSetting(value)
+		if err != nil {
+			return nil, fmt.Errorf("setting %q: %w", name, err)
+		}
+		if err := addSetting(settings, name, text); err != nil {
+			return nil, err
+		}
+	}
+	return settings, nil
+}
+
+func jsonSetting(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			part, err := jsonSetting(item)
+			if err != nil {
+				return "", err
+			}
+			if strings.Contains(part, ",") {
+				return "", errListComma
+			}
+			parts = append(parts, part)
+		}
+		return strings.Join(parts, ","), nil
+	case map[string]interface{}:
+		pairs := make([]string, 0, len(v))
+		for k, item := range v {
+			part, err := jsonSetting(item)
+			if err != nil {
+				return "", err
+			}
+			pair, err := settingPair(k, part)
+			if err != nil {
+				return "", err
+			}
+			pairs = append(pairs, pair)
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ","), nil
+	default:
+		return "", fmt.Errorf("unsupported value %v", value)
+	}
+}
+
+// parseYAMLSettings reads a YAML mapping of settings. Values may be scalars,
+// lists of scalars, which become comma-separated lists, or mappings of
+// scalars, which become key=value pairs; anything nested deeper is rejected
+// rather than misread.
+func parseYAMLSettings(data []byte) (map[string]string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse YAML: %w", err)
+	}
+	settings := make(map[string]string)
+	if len(doc.Content) == 0 {
+		return settings, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: want a mapping of settings", root.Line)
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		name, value := root.Content[i], root.Content[i+1]
+		text, err := yamlSetting(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: setting %q: %w", value.Line, name.Value, err)
+		}
+		if err := addSetting(settings, name.Value, text); err != nil {
+			return nil, fmt.Errorf("line %d: %w", name.Line, err)
+		}
+	}
+	return settings, nil
+}
+
+func yamlSetting(node *yaml.Node) (string, error) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag == "!!null" {
+			return "", errors.New("null values are not supported")
+		}
+		return node.Value, nil
+	case yaml.SequenceNode:
+		parts := make([]string, 0, len(node.Content))
+		for _, item := range node.Content {
+			if item.Kind == yaml.AliasNode {
+				item = item.Alias
+			}
+			if item.Kind != yaml.ScalarNode || item.Tag == "!!null" {
+				return "", errors.New("list items must be plain values")
+			}
+			if strings.Contains(item.Value, ",") {
+				return "", errListComma
+			}
+			parts = append(parts, item.Value)
+		}
+		return strings.Join(parts, ","), nil
+	case yaml.MappingNode:
+		pairs := make([]string, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, item := node.Content[i], node.Content[i+1]
+			if item.Kind == yaml.AliasNode {
+				item = item.Alias
+			}
+			if key.Kind != yaml.ScalarNode || item.Kind != yaml.ScalarNode || item.Tag == "!!null" {
+				return "", errors.New("mapping entries must be plain values")
+			}
+			pair, err := settingPair(key.Value, item.Value)
+			if err != nil {
+				return "", err
+			}
+			pairs = append(pairs, pair)
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ","), nil
+	default:
+		return "", errors.New("nested values are not supported")
+	}
+}
@@ -0,0 +1,124 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const addressSearchPath = "/Shared/AddressSearch"
+
+var (
+	// ErrInvalidPostcode indicates the postcode is not a well-formed UK postcode.
+	ErrInvalidPostcode = errors.New("invalid postcode")
+	// ErrNoAddresses indicates the council returned no addresses for a postcode.
+	ErrNoAddresses = errors.New("no addresses found for postcode")
+)
+
+// postcodeRegex matches a normalized UK postcode: an outward code (one or two
+// letters, a digit, then an optional letter or digit, or the special GIR)
+// followed by a single space and the inward code.
+var postcodeRegex = regexp.MustCompile(`^([A-Z]{1,2}[0-9][A-Z0-9]?|GIR) [0-9][A-Z]{2}$`)
+
+// postcodeInwardLength is the length of the inward code, the part after the
+// space.
+const postcodeInwardLength = 3
+
+// NormalizePostcode uppercases postcode and rewrites its spacing to the
+// canonical single space before the three-character inward code, so "ig11aa"
+// and " IG1  1AA" both become "IG1 1AA". It returns ErrInvalidPostcode when
+// the result is not a well-formed UK postcode.
+func NormalizePostcode(postcode string) (string, error) {
+	compact := strings.ToUpper(strings.Join(strings.Fields(postcode), ""))
+	if len(compact) <= postcodeInwardLength {
+		return "", ErrInvalidPostcode
+	}
+	split := len(compact) - postcodeInwardLength
+	normalized := compact[:split] + " " + compact[split:]
+	if !postcodeRegex.MatchString(normalized) {
+		return "", ErrInvalidPostcode
+	}
+	return normalized, nil
+}
+
+// AddressMatch is a candidate property returned by a postcode search.
+type AddressMatch struct {
+	UPRN        string
+	AddressLine string
+}
+
+// LookupAddresses searches the council's address finder for postcode and
+// returns the matching properties and their UPRNs.
+func (s *Scraper) LookupAddresses(ctx context.Context, postcode string) ([]AddressMatch, error) {
+	postcode, err := NormalizePostcode(postcode)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s%s?%s", s.cfg.BaseURL, addressSearchPath, url.Values{"postcode": {postcode}}.Encode())
+	userAgent := s.nextUserAgent()
+	resp, err := s.doWithRetry(ctx, s.client, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		s.setHeaders(req, userAgent)
+		return req, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("address search: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("address search: unexpected status %d", resp.StatusCode)
+	}
+
+	body, err := s.readBody(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := parseAddresses(body)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, ErrNoAddresses
+	}
+	return matches, nil
+}
+
+// parseAddresses reads the <option value="UPRN">address</option> list the
+// address finder renders, skipping placeholder options without a UPRN.
+func parseAddresses(body []byte) ([]AddressMatch, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []AddressMatch
+	seen := make(map[string]struct{})
+	doc.Find("option[value]").Each(func(_ int, opt *goquery.Selection) {
+		uprn := strings.TrimSpace(attrValue(opt, "value"))
+		if digitOnly.FindString(uprn) != uprn || uprn == "" {
+			return
+		}
+		if _, ok := seen[uprn]; ok {
+			return
+		}
+		seen[uprn] = struct{}{}
+		matches = append(matches, AddressMatch{
+			UPRN:        uprn,
+			AddressLine: normalizeSpaces(opt.Text()),
+		})
+	})
+	return matches, nil
+}
@@ -6,12 +6,16 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"math"
+	"math/rand/v2"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
 	"regexp"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
@@ -22,31 +26,173 @@ var (
 	ErrAddressSetup = errors.New("failed to seed Redbridge address cookie")
 	// ErrNoCollections indicates the scraper could not find any collection slots.
 	ErrNoCollections = errors.New("no collections found in schedule")
+	// ErrBodyTooLarge indicates a response exceeded Config.MaxBodyBytes.
+	ErrBodyTooLarge = errors.New("response body exceeds size limit")
 )
 
 var digitOnly = regexp.MustCompile(`\d+`)
 
+// skippedRow matches schedule entries that mark a week with no collection,
+// typically around bank holidays.
+var skippedRow = regexp.MustCompile(`(?i)\b(no collection|cancell?ed|suspended)\b`)
+
+const (
+	defaultRetryBaseDelay = 200 * time.Millisecond
+	defaultRequestDelay   = 150 * time.Millisecond
+	defaultMaxBodyBytes   = 5 << 20
+	// Connection pool defaults; MaxIdleConns and IdleConnTimeout match
+	// http.DefaultTransport.
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = 4
+	defaultIdleConnTimeout     = 90 * time.Second
+	// yearRolloverDays is how far before the scrape a yearless date may fall
+	// before it is taken to mean next year, e.g. "3 January" seen in December.
+	yearRolloverDays = 60
+)
+
 // Config describes how to scrape the council site.
 type Config struct {
-	BaseURL        string
-	SchedulePath   string
-	UPRN           string
-	AddressLine    string
-	Postcode       string
-	Latitude       string
-	Longitude      string
-	UserAgent      string
-	StartHour      int
-	RequestTimeout time.Duration
-	Timezone       string
+	BaseURL      string
+	SchedulePath string
+	UPRN         string
+	AddressLine  string
+	Postcode     string
+	Latitude     string
+	Longitude    string
+	UserAgent    string
+	UserAgents   []string
+	StartHour    int
+	// StartHourByType overrides StartHour for specific waste types, matched
+	// case-insensitively.
+	StartHourByType map[string]int
+	RequestTimeout  time.Duration
+	Timezone        string
+	MaxRetries      int
+	RetryBaseDelay  time.Duration
+	// RequestDelay is the pause between SaveAddress and the schedule fetch;
+	// each pause is randomised by ±50% so instances don't scrape in lockstep.
+	RequestDelay time.Duration
+	// Parsers are extra page layouts tried, in order, after the built-in ones.
+	Parsers []Parser
+	// TypeAliases maps council labels to canonical type names, matched
+	// case-insensitively, on top of the built-in defaultTypeAliases.
+	TypeAliases map[string]string
+	// MaxBodyBytes caps how much of a response is read before giving up with
+	// ErrBodyTooLarge.
+	MaxBodyBytes int64
+	// ProxyURL routes requests through an http, https or socks5 proxy. When
+	// empty the standard HTTP_PROXY/HTTPS_PROXY environment variables apply.
+	ProxyURL string
+	// OriginUsername and OriginPassword, when a username is set, are sent as
+	// HTTP basic auth on every request to BaseURL.
+	OriginUsername string
+	OriginPassword string
+	// SaveAddressMethod is how the address is seeded: http.MethodGet (the
+	// default) sends the values as query parameters, http.MethodPost as a
+	// form-encoded body, for council deployments that only accept POST.
+	SaveAddressMethod string
+	// MaxIdleConns, MaxIdleConnsPerHost and IdleConnTimeout tune the
+	// transport's keep-alive pool; zero keeps the defaults.
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+	// Logger receives warnings about parts of a page that could not be
+	// parsed; nil uses slog.Default.
+	Logger *slog.Logger
+}
+
+// defaultTypeAliases folds the labels the council has been seen to use onto
+// the names the rest of the service expects.
+var defaultTypeAliases = map[string]string{
+	"mixed recycling":    "Recycling",
+	"dry recycling":      "Recycling",
+	"recycling waste":    "Recycling",
+	"general waste":      "Refuse",
+	"household waste":    "Refuse",
+	"rubbish":            "Refuse",
+	"garden":             "Garden Waste",
+	"green garden waste": "Garden Waste",
+	"food":               "Food Waste",
+	"food caddy":         "Food Waste",
+	"food waste caddy":   "Food Waste",
 }
 
+// Parser extracts collections from one schedule page layout. It returns no
+// collections when the document does not match that layout, so the next
+// parser can be tried.
+type Parser func(*goquery.Document) []Collection
+
+// Collection frequencies inferred from the gaps between dates of the same type.
+const (
+	FrequencyWeekly      = "weekly"
+	FrequencyFortnightly = "fortnightly"
+	FrequencyIrregular   = "irregular"
+	FrequencyUnknown     = "unknown"
+)
+
+// Collection sources for the built-in page layouts.
+const (
+	SourcePrimary  = "primary"
+	SourceFallback = "fallback"
+)
+
+// NoCollectionSuffix is appended to the type of entries the council marks as
+// skipped, so the gap is still visible in the schedule.
+const NoCollectionSuffix = " (No Collection)"
+
+const defaultSkippedNote = "No collection this week."
+
+// GardenStatus describes the paid garden-waste service for the address, as
+// far as the schedule page shows it.
+type GardenStatus string
+
+// Garden-waste service states. GardenUnknown covers pages that don't use the
+// container layout and notices that match no known wording.
+const (
+	GardenActive        GardenStatus = "active"
+	GardenSuspended     GardenStatus = "suspended"
+	GardenNotSubscribed GardenStatus = "not-subscribed"
+	GardenUnknown       GardenStatus = "unknown"
+)
+
+var (
+	gardenSuspendedWords     = []string{"resume", "suspend", "paused", "winter break"}
+	gardenNotSubscribedWords = []string{"subscri", "sign up", "not registered", "register for"}
+)
+
 // Collection represents a single waste collection slot.
 type Collection struct {
 	Date         time.Time
 	Type         string
 	Instructions []Instruction
 	Note         string
+	Frequency    string
+	// Source names the parser that produced the entry: SourcePrimary,
+	// SourceFallback, or "custom-N" for the Nth Config.Parsers entry.
+	Source string
+}
+
+// Skipped reports whether the entry marks a cancelled collection.
+func (c Collection) Skipped() bool {
+	return strings.HasSuffix(c.Type, NoCollectionSuffix)
+}
+
+// Key identifies the collection by calendar date in its own location, type
+// and note, so entries for the same day compare equal whatever their start
+// hour, location or monotonic clock reading.
+func (c Collection) Key() string {
+	return c.DayKey() + "|" + c.Note
+}
+
+// DayKey is Key without the note: entries that share it are the same
+// collection, however differently the council annotated them.
+func (c Collection) DayKey() string {
+	return c.Date.Format("2006-01-02") + "|" + c.Type
+}
+
+// Equal reports whether c and other have the same Key.
+func (c Collection) Equal(other Collection) bool {
+	return c.Key() == other.Key()
 }
 
 // Instruction captures a single guidance line and any related links.
@@ -59,7 +205,14 @@ type Instruction struct {
 type Scraper struct {
 	cfg      Config
 	location *time.Location
+	logger   *slog.Logger
 	client   *http.Client
+	uaIndex  atomic.Uint64
+	random   func() float64
+	now      func() time.Time
+	parsers  []namedParser
+	aliases  map[string]string
+	garden   atomic.Value
 }
 
 // New constructs a Scraper instance.
@@ -74,46 +227,126 @@ func New(cfg Config) (*Scraper, error) {
 	if err != nil {
 		return nil, fmt.Errorf("load timezone: %w", err)
 	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.RetryBaseDelay <= 0 {
+		cfg.RetryBaseDelay = defaultRetryBaseDelay
+	}
+	if cfg.RequestDelay <= 0 {
+		cfg.RequestDelay = defaultRequestDelay
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = defaultMaxBodyBytes
+	}
+	switch cfg.SaveAddressMethod = strings.ToUpper(cfg.SaveAddressMethod); cfg.SaveAddressMethod {
+	case "":
+		cfg.SaveAddressMethod = http.MethodGet
+	case http.MethodGet, http.MethodPost:
+	default:
+		return nil, fmt.Errorf("unsupported SaveAddress method %q", cfg.SaveAddressMethod)
+	}
+	if cfg.MaxIdleConns <= 0 {
+		cfg.MaxIdleConns = defaultMaxIdleConns
+	}
+	if cfg.MaxIdleConnsPerHost <= 0 {
+		cfg.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+	if cfg.IdleConnTimeout <= 0 {
+		cfg.IdleConnTimeout = defaultIdleConnTimeout
+	}
 
 	transport := http.DefaultTransport.(*http.Transport).Clone()
-	transport.MaxIdleConnsPerHost = 4
+	transport.MaxIdleConns = cfg.MaxIdleConns
+	transport.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	transport.IdleConnTimeout = cfg.IdleConnTimeout
+	if cfg.ProxyURL != "" {
+		proxy, err := parseProxyURL(cfg.ProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
 
-	return &Scraper{
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	s := &Scraper{
 		cfg:      cfg,
 		location: loc,
+		logger:   logger,
 		client: &http.Client{
 			Timeout:   cfg.RequestTimeout,
 			Transport: transport,
 		},
-	}, nil
+		random: rand.Float64,
+		now:    time.Now,
+	}
+	s.parsers = []namedParser{
+		{name: SourcePrimary, parse: s.parseContainerLayout},
+		{name: SourceFallback, parse: s.parseSectionLayout},
+	}
+	for i, parse := range cfg.Parsers {
+		s.parsers = append(s.parsers, namedParser{name: fmt.Sprintf("custom-%d", i+1), parse: parse})
+	}
+	s.aliases = make(map[string]string, len(defaultTypeAliases)+len(cfg.TypeAliases))
+	for alias, canonical := range defaultTypeAliases {
+		s.aliases[alias] = canonical
+	}
+	for alias, canonical := range cfg.TypeAliases {
+		s.aliases[strings.ToLower(normalizeSpaces(alias))] = canonical
+	}
+	return s, nil
 }
 
-// FetchCollections scrapes the remote HTML document for upcoming collection dates.
-func (s *Scraper) FetchCollections(ctx context.Context) ([]Collection, error) {
-	jar, err := cookiejar.New(nil)
+// parseProxyURL validates raw as a proxy URL with a scheme net/http can dial.
+func parseProxyURL(raw string) (*url.URL, error) {
+	proxy, err := url.Parse(raw)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
 	}
+	switch proxy.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q: want http, https or socks5", proxy.Scheme)
+	}
+	if proxy.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: missing host", raw)
+	}
+	return proxy, nil
+}
 
-	client := *s.client
-	client.Jar = jar
-
-	if err := s.seedAddress(ctx, &client); err != nil {
+// FetchCollections scrapes the remote HTML document for upcoming collection dates.
+func (s *Scraper) FetchCollections(ctx context.Context) ([]Collection, error) {
+	body, err := s.FetchHTML(ctx)
+	if errors.Is(err, ErrAddressSetup) {
+		// The schedule page sometimes accepts the UPRN directly, so try that
+		// before giving up on a missing preference cookie.
+		if collections, fallbackErr := s.fetchCollectionsByQuery(ctx); fallbackErr == nil {
+			return collections, nil
+		}
 		return nil, err
 	}
-
-	// Small pause to avoid hammering the origin immediately.
-	select {
-	case <-time.After(150 * time.Millisecond):
-	case <-ctx.Done():
-		return nil, ctx.Err()
+	if err != nil {
+		return nil, err
 	}
+	return s.collectionsFrom(body)
+}
 
-	body, err := s.fetchSchedule(ctx, &client)
+// fetchCollectionsByQuery fetches the schedule with ?uprn= instead of the
+// SaveAddress cookie.
+func (s *Scraper) fetchCollectionsByQuery(ctx context.Context) ([]Collection, error) {
+	body, err := s.fetchSchedule(ctx, s.client, s.nextUserAgent(), url.Values{"uprn": {s.cfg.UPRN}})
 	if err != nil {
 		return nil, err
 	}
+	return s.collectionsFrom(body)
+}
 
+// collectionsFrom parses a schedule page into date-ordered collections.
+func (s *Scraper) collectionsFrom(body []byte) ([]Collection, error) {
 	collections, err := s.parseCollections(body)
 	if err != nil {
 		return nil, err
@@ -126,11 +359,67 @@ func (s *Scraper) FetchCollections(ctx context.Context) ([]Collection, error) {
 	sort.Slice(collections, func(i, j int) bool {
 		return collections[i].Date.Before(collections[j].Date)
 	})
+	assignFrequencies(collections)
 
 	return collections, nil
 }
 
-func (s *Scraper) seedAddress(ctx context.Context, client *http.Client) error {
+// ValidateAddress performs only the SaveAddress handshake, reporting
+// ErrAddressSetup when the council doesn't accept the configured address. It
+// never requests the schedule, so it is a cheap check of UPRN and address
+// settings.
+func (s *Scraper) ValidateAddress(ctx context.Context) error {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return err
+	}
+	client := *s.client
+	client.Jar = jar
+	return s.seedAddress(ctx, &client, s.nextUserAgent())
+}
+
+// FetchHTML performs the SaveAddress handshake and returns the raw schedule
+// page without parsing it.
+func (s *Scraper) FetchHTML(ctx context.Context) ([]byte, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := *s.client
+	client.Jar = jar
+	userAgent := s.nextUserAgent()
+
+	if err := s.seedAddress(ctx, &client, userAgent); err != nil {
+		return nil, err
+	}
+
+	// Small pause to avoid hammering the origin immediately.
+	select {
+	case <-time.After(jitter(s.cfg.RequestDelay, s.random())):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	return s.fetchSchedule(ctx, &client, userAgent, nil)
+}
+
+// nextUserAgent rotates through the configured user agents, falling back to
+// the single UserAgent when no list is set.
+func (s *Scraper) nextUserAgent() string {
+	if len(s.cfg.UserAgents) == 0 {
+		return s.cfg.UserAgent
+	}
+	i := s.uaIndex.Add(1) - 1
+	return s.cfg.UserAgents[i%uint64(len(s.cfg.UserAgents))]
+}
+
+// jitter spreads d across [0.5d, 1.5d) using r, a value in [0, 1).
+func jitter(d time.Duration, r float64) time.Duration {
+	return d/2 + time.Duration(float64(d)*r)
+}
+
+func (s *Scraper) seedAddress(ctx context.Context, client *http.Client, userAgent string) error {
 	endpoint := fmt.Sprintf("%s/Shared/SaveAddress", s.cfg.BaseURL)
 	values := url.Values{}
 	values.Set("uprn", s.cfg.UPRN)
@@ -146,15 +435,27 @@ func (s *Scraper) seedAddress(ctx context.Context, client *http.Client) error {
 	if s.cfg.Longitude != "" {
 		values.Set("longitude", s.cfg.Longitude)
 	}
-	values.Set("_", fmt.Sprintf("%d", time.Now().UnixMilli()))
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+values.Encode(), nil)
-	if err != nil {
-		return err
-	}
-	req.Header.Set("User-Agent", s.cfg.UserAgent)
 
-	resp, err := client.Do(req)
+	var req *http.Request
+	resp, err := s.doWithRetry(ctx, client, func() (*http.Request, error) {
+		var r *http.Request
+		var err error
+		if s.cfg.SaveAddressMethod == http.MethodPost {
+			r, err = http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(values.Encode()))
+		} else {
+			values.Set("_", fmt.Sprintf("%d", time.Now().UnixMilli()))
+			r, err = http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+values.Encode(), nil)
+		}
+		if err != nil {
+			return nil, err
+		}
+		s.setHeaders(r, userAgent)
+		if r.Method == http.MethodPost {
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		req = r
+		return r, nil
+	})
 	if err != nil {
 		return fmt.Errorf("save address: %w", err)
 	}
@@ -188,15 +489,19 @@ func (s *Scraper) seedAddress(ctx context.Context, client *http.Client) error {
 	return nil
 }
 
-func (s *Scraper) fetchSchedule(ctx context.Context, client *http.Client) ([]byte, error) {
+func (s *Scraper) fetchSchedule(ctx context.Context, client *http.Client, userAgent string, query url.Values) ([]byte, error) {
 	endpoint := fmt.Sprintf("%s%s", s.cfg.BaseURL, s.cfg.SchedulePath)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
-	if err != nil {
-		return nil, err
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
 	}
-	req.Header.Set("User-Agent", s.cfg.UserAgent)
-
-	resp, err := client.Do(req)
+	resp, err := s.doWithRetry(ctx, client, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		s.setHeaders(req, userAgent)
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("fetch schedule: %w", err)
 	}
@@ -206,107 +511,190 @@ func (s *Scraper) fetchSchedule(ctx context.Context, client *http.Client) ([]byt
 		return nil, fmt.Errorf("fetch schedule: unexpected status %d", resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	return s.readBody(resp.Body)
+}
+
+// setHeaders applies the per-request user agent and any origin credentials.
+func (s *Scraper) setHeaders(req *http.Request, userAgent string) {
+	req.Header.Set("User-Agent", userAgent)
+	if s.cfg.OriginUsername != "" {
+		req.SetBasicAuth(s.cfg.OriginUsername, s.cfg.OriginPassword)
+	}
+}
+
+// readBody reads at most MaxBodyBytes from r, failing with ErrBodyTooLarge
+// rather than truncating a larger body.
+func (s *Scraper) readBody(r io.Reader) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r, s.cfg.MaxBodyBytes+1))
 	if err != nil {
 		return nil, err
 	}
-
+	if int64(len(body)) > s.cfg.MaxBodyBytes {
+		return nil, fmt.Errorf("%w (%d bytes)", ErrBodyTooLarge, s.cfg.MaxBodyBytes)
+	}
 	return body, nil
 }
 
+// doWithRetry issues the request built by newReq, retrying network errors and
+// 5xx responses with exponential backoff. The final response is returned as-is
+// once retries are exhausted so callers can inspect its status.
+func (s *Scraper) doWithRetry(ctx context.Context, client *http.Client, newReq func() (*http.Request, error)) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := newReq()
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Do(req)
+		if err == nil && resp.StatusCode < 500 {
+			return resp, nil
+		}
+		if attempt >= s.cfg.MaxRetries || ctx.Err() != nil {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-time.After(s.cfg.RetryBaseDelay << attempt):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// namedParser pairs a Parser with the Source it records.
+type namedParser struct {
+	name  string
+	parse Parser
+}
+
+// parseCollections tries each parser in turn and returns the first non-empty
+// result, tagged with that parser's Source. It also records the page's
+// garden-waste status for GardenStatus.
 func (s *Scraper) parseCollections(body []byte) ([]Collection, error) {
 	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
+	s.garden.Store(classifyGarden(doc))
+
+	for _, p := range s.parsers {
+		if results := p.parse(doc); len(results) > 0 {
+			for i := range results {
+				results[i].Source = p.name
+			}
+			return s.normalizeTypes(results), nil
+		}
+	}
+	return nil, ErrNoCollections
+}
 
+// normalizeType maps a council label onto its canonical type name, keeping
+// any NoCollectionSuffix in place.
+func (s *Scraper) normalizeType(label string) string {
+	label = normalizeSpaces(label)
+	base, skipped := strings.CutSuffix(label, NoCollectionSuffix)
+	if canonical, ok := s.aliases[strings.ToLower(base)]; ok {
+		base = canonical
+	}
+	if skipped {
+		return base + NoCollectionSuffix
+	}
+	return base
+}
+
+// normalizeTypes canonicalises every collection's type, merging entries that
+// become duplicates of an earlier one on the same date.
+func (s *Scraper) normalizeTypes(collections []Collection) []Collection {
+	var merged []Collection
+	seen := make(map[string]int, len(collections))
+	for _, c := range collections {
+		c.Type = s.normalizeType(c.Type)
+		key := c.DayKey()
+		if idx, exists := seen[key]; exists {
+			merged[idx].Note = appendNote(merged[idx].Note, c.Note)
+			if len(merged[idx].Instructions) == 0 {
+				merged[idx].Instructions = cloneInstructions(c.Instructions)
+			}
+			continue
+		}
+		seen[key] = len(merged)
+		merged = append(merged, c)
+	}
+	return merged
+}
+
+// parseContainerLayout handles the long-standing page design, where each
+// waste type has its own *-container block of day/month pairs.
+func (s *Scraper) parseContainerLayout(doc *goquery.Document) []Collection {
 	container := doc.Find(".your-collection-schedule-container").First()
 	if container.Length() == 0 {
-		return nil, ErrNoCollections
+		return nil
 	}
 
 	defs := []blockDefinition{
 		{
-			blockSelector: ".refuse-container",
-			entrySelector: ".collectionDates-container .garden-collection-postdate",
-			daySelector:   ".refuse-garden-collection-day-numeric",
-			monthSelector: ".refuse-collection-month",
-			wasteType:     "Refuse",
+			blockSelector:   ".refuse-container",
+			entrySelector:   ".collectionDates-container .garden-collection-postdate",
+			daySelector:     ".refuse-garden-collection-day-numeric",
+			monthSelector:   ".refuse-collection-month",
+			weekdaySelector: ".refuse-collection-day-of-week",
+			wasteType:       "Refuse",
 		},
 		{
-			blockSelector: ".recycle-container",
-			entrySelector: ".collectionDates-container .garden-collection-postdate",
-			daySelector:   ".recycling-garden-collection-day-numeric",
-			monthSelector: ".recycling-collection-month",
-			wasteType:     "Recycling",
+			blockSelector:   ".recycle-container",
+			entrySelector:   ".collectionDates-container .garden-collection-postdate",
+			daySelector:     ".recycling-garden-collection-day-numeric",
+			monthSelector:   ".recycling-collection-month",
+			weekdaySelector: ".recycling-collection-day-of-week",
+			wasteType:       "Recycling",
 		},
 		{
-			blockSelector: ".garden-container",
-			entrySelector: ".collectionDates-container .garden-collection-postdate",
-			daySelector:   ".garden-collection-day-numeric, .garden-garden-collection-day-numeric",
-			monthSelector: ".garden-collection-month",
-			wasteType:     "Garden Waste",
+			blockSelector:   ".garden-container",
+			entrySelector:   ".collectionDates-container .garden-collection-postdate",
+			daySelector:     ".garden-collection-day-numeric, .garden-garden-collection-day-numeric",
+			monthSelector:   ".garden-collection-month",
+			weekdaySelector: ".garden-collection-day-of-week",
+			wasteType:       "Garden Waste",
 		},
 		{
-			blockSelector: ".foodwasteCollectionDay",
-			entrySelector: ".collectionDates-container .garden-collection-postdate",
-			daySelector:   ".food-garden-collection-day-numeric",
-			monthSelector: ".food-collection-month",
-			wasteType:     "Food Waste",
+			blockSelector:   ".foodwasteCollectionDay, .food-container",
+			entrySelector:   ".collectionDates-container .garden-collection-postdate",
+			daySelector:     ".food-garden-collection-day-numeric",
+			monthSelector:   ".food-collection-month",
+			weekdaySelector: ".food-collection-day-of-week",
+			wasteType:       "Food Waste",
 		},
 	}
 
 	var results []Collection
-	seen := make(map[string]int)
 	var gardenNotice string
 
+	// Blocks are parsed independently, so markup the parser can't read in one
+	// waste type's block costs only that type's dates.
 	for _, def := range defs {
 		block := container.Find(def.blockSelector)
 		if block.Length() == 0 {
 			continue
 		}
-		instructions := extractInstructions(block, s.cfg.BaseURL)
-		blockNotice := ""
-		if def.wasteType == "Garden Waste" {
-			blockNotice = extractGardenNotice(block)
+		collections, err := s.parseBlock(block, def)
+		if err != nil {
+			s.logger.Warn("schedule block failed to parse",
+				slog.String("block", def.blockSelector),
+				slog.String("type", def.wasteType),
+				slog.Int("parsed", len(collections)),
+				slog.String("error", err.Error()),
+			)
 		}
-		added := 0
-		block.Find(def.entrySelector).Each(func(_ int, sel *goquery.Selection) {
-			dayText := strings.TrimSpace(sel.Find(def.daySelector).Text())
-			monthText := strings.TrimSpace(sel.Find(def.monthSelector).Text())
-			if dayText == "" || monthText == "" {
-				return
-			}
-
-			date, err := s.parseDate(dayText, monthText)
-			if err != nil {
-				return
-			}
+		results = append(results, collections...)
 
-			note := extractNoteText(sel, def)
-			key := fmt.Sprintf("%s|%s", date.Format(time.RFC3339), def.wasteType)
-			if idx, exists := seen[key]; exists {
-				if note != "" && results[idx].Note == "" {
-					results[idx].Note = note
-				}
-				if len(instructions) > 0 && len(results[idx].Instructions) == 0 {
-					results[idx].Instructions = cloneInstructions(instructions)
-				}
-				return
+		if def.wasteType == "Garden Waste" && len(collections) == 0 {
+			if notice := extractGardenNotice(block); notice != "" {
+				gardenNotice = notice
 			}
-			seen[key] = len(results)
-
-			results = append(results, Collection{
-				Date:         date,
-				Type:         def.wasteType,
-				Instructions: cloneInstructions(instructions),
-				Note:         note,
-			})
-			added++
-		})
-
-		if def.wasteType == "Garden Waste" && added == 0 && blockNotice != "" {
-			gardenNotice = blockNotice
 		}
 	}
 
@@ -319,10 +707,123 @@ func (s *Scraper) parseCollections(body []byte) ([]Collection, error) {
 		}
 	}
 
+	return results
+}
+
+// parseBlock reads one waste type's block of the container layout. Entries
+// whose date can't be read are skipped; the returned error counts them, along
+// with the first failure, next to whatever the rest of the block yielded. A
+// panic while reading the block is reported the same way.
+func (s *Scraper) parseBlock(block *goquery.Selection, def blockDefinition) (results []Collection, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	instructions := extractInstructions(block, s.cfg.BaseURL)
+	seen := make(map[string]int)
+	failed := 0
+	var firstErr error
+	block.Find(def.entrySelector).Each(func(_ int, sel *goquery.Selection) {
+		dayText := strings.TrimSpace(sel.Find(def.daySelector).Text())
+		monthText := strings.TrimSpace(sel.Find(def.monthSelector).Text())
+		if dayText == "" || monthText == "" {
+			return
+		}
+
+		date, err := s.parseDate(dayText, monthText, def.wasteType)
+		if err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%q %q: %w", dayText, monthText, err)
+			}
+			return
+		}
+
+		note := extractNoteText(sel, def)
+		if warning := weekdayMismatch(date, sel.Find(def.weekdaySelector).Text()); warning != "" {
+			note = appendNote(note, warning)
+		}
+		wasteType := def.wasteType
+		if skippedRow.MatchString(normalizeSpaces(sel.Text())) {
+			wasteType, note = skippedEntry(wasteType, note)
+		}
+		key := Collection{Date: date, Type: wasteType}.DayKey()
+		if idx, exists := seen[key]; exists {
+			if note != "" && results[idx].Note == "" {
+				results[idx].Note = note
+			}
+			if len(instructions) > 0 && len(results[idx].Instructions) == 0 {
+				results[idx].Instructions = cloneInstructions(instructions)
+			}
+			return
+		}
+		seen[key] = len(results)
+
+		results = append(results, Collection{
+			Date:         date,
+			Type:         wasteType,
+			Instructions: cloneInstructions(instructions),
+			Note:         note,
+		})
+	})
+
+	if failed > 0 {
+		return results, fmt.Errorf("%d unreadable entries, first %w", failed, firstErr)
+	}
 	return results, nil
 }
 
-func (s *Scraper) parseDate(dayText, monthText string) (time.Time, error) {
+// parseSectionLayout handles the redesigned page the council A/B tests, where
+// each waste type is a .bin-collection section carrying its type in data-type
+// and its dates as <time datetime="YYYY-MM-DD"> elements.
+func (s *Scraper) parseSectionLayout(doc *goquery.Document) []Collection {
+	var results []Collection
+	seen := make(map[string]struct{})
+	doc.Find(".bin-collection").Each(func(_ int, section *goquery.Selection) {
+		wasteType := normalizeSpaces(attrValue(section, "data-type"))
+		if wasteType == "" {
+			wasteType = normalizeSpaces(section.Find("h2, h3").First().Text())
+		}
+		if wasteType == "" {
+			return
+		}
+		instructions := extractInstructions(section, s.cfg.BaseURL)
+		// START_HOUR_BY_TYPE names canonical types, so resolve the label's
+		// alias before looking up its hour.
+		hour := s.startHour(s.normalizeType(wasteType))
+
+		section.Find("time[datetime]").Each(func(_ int, t *goquery.Selection) {
+			day, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(attrValue(t, "datetime")), s.location)
+			if err != nil {
+				return
+			}
+			date := time.Date(day.Year(), day.Month(), day.Day(), hour, 0, 0, 0, s.location)
+
+			entryType, note := wasteType, ""
+			if row := t.Closest("li"); row.Length() > 0 && skippedRow.MatchString(normalizeSpaces(row.Text())) {
+				entryType, note = skippedEntry(wasteType, strings.TrimSpace(strings.TrimPrefix(normalizeSpaces(row.Text()), normalizeSpaces(t.Text()))))
+			}
+
+			key := Collection{Date: date, Type: entryType}.DayKey()
+			if _, exists := seen[key]; exists {
+				return
+			}
+			seen[key] = struct{}{}
+
+			results = append(results, Collection{
+				Date:         date,
+				Type:         entryType,
+				Instructions: cloneInstructions(instructions),
+				Note:         note,
+			})
+		})
+	})
+	return results
+}
+
+func (s *Scraper) parseDate(dayText, monthText, wasteType string) (time.Time, error) {
 	dayDigits := digitOnly.FindString(dayText)
 	if dayDigits == "" {
 		return time.Time{}, errors.New("invalid day")
@@ -335,25 +836,184 @@ func (s *Scraper) parseDate(dayText, monthText string) (time.Time, error) {
 
 	full := fmt.Sprintf("%s %s", dayDigits, monthClean)
 	parsed, err := time.ParseInLocation("2 January 2006", full, s.location)
+	if err != nil {
+		// Some entries give only the month, so infer the year from the scrape.
+		parsed, err = s.parseYearless(full)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	return time.Date(parsed.Year(), parsed.Month(), parsed.Day(), s.startHour(wasteType), 0, 0, 0, s.location), nil
+}
+
+// parseYearless parses a "2 January" date into the year that places it no
+// more than yearRolloverDays before the scrape: January dates listed in a
+// December scrape belong to next year, and a late-December date seen in early
+// January belongs to last year.
+func (s *Scraper) parseYearless(value string) (time.Time, error) {
+	var parsed time.Time
+	var err error
+	for _, layout := range []string{"2 January", "2 Jan"} {
+		if parsed, err = time.ParseInLocation(layout, value, s.location); err == nil {
+			break
+		}
+	}
 	if err != nil {
 		return time.Time{}, err
 	}
 
-	return time.Date(parsed.Year(), parsed.Month(), parsed.Day(), s.cfg.StartHour, 0, 0, 0, s.location), nil
+	now := s.now().In(s.location)
+	earliest := now.AddDate(0, 0, -yearRolloverDays)
+	date := time.Date(now.Year(), parsed.Month(), parsed.Day(), 0, 0, 0, 0, s.location)
+	switch {
+	case date.Before(earliest):
+		date = date.AddDate(1, 0, 0)
+	case !date.Before(earliest.AddDate(1, 0, 0)):
+		date = date.AddDate(-1, 0, 0)
+	}
+	return date, nil
+}
+
+// weekdayMismatch cross-checks date against the weekday name the council
+// shows beside it, so a typo in the day or month doesn't silently move a
+// collection. It returns a warning note on mismatch, or "" when the label
+// agrees, is absent, or isn't a recognisable weekday.
+func weekdayMismatch(date time.Time, label string) string {
+	label = strings.ToLower(normalizeSpaces(label))
+	if len(label) < 3 {
+		return ""
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if label != name && label != name[:3] {
+			continue
+		}
+		if d == date.Weekday() {
+			return ""
+		}
+		return fmt.Sprintf("The council lists this as %s but %s is a %s; check the date.", d, date.Format("2 January"), date.Weekday())
+	}
+	return ""
+}
+
+// startHour returns the configured collection hour for wasteType.
+func (s *Scraper) startHour(wasteType string) int {
+	if hour, ok := s.cfg.StartHourByType[wasteType]; ok {
+		return hour
+	}
+	for t, hour := range s.cfg.StartHourByType {
+		if strings.EqualFold(t, wasteType) {
+			return hour
+		}
+	}
+	return s.cfg.StartHour
+}
+
+// assignFrequencies sets Frequency on every collection using the most common
+// gap between consecutive dates of the same type. Collections must be sorted.
+func assignFrequencies(collections []Collection) {
+	last := make(map[string]time.Time)
+	gaps := make(map[string]map[int]int)
+	for _, c := range collections {
+		if prev, ok := last[c.Type]; ok {
+			gap := int(math.Round(c.Date.Sub(prev).Hours() / 24))
+			if gaps[c.Type] == nil {
+				gaps[c.Type] = make(map[int]int)
+			}
+			gaps[c.Type][gap]++
+		}
+		last[c.Type] = c.Date
+	}
+
+	frequencies := make(map[string]string, len(last))
+	for wasteType := range last {
+		frequencies[wasteType] = frequencyFromGaps(gaps[wasteType])
+	}
+	for i := range collections {
+		collections[i].Frequency = frequencies[collections[i].Type]
+	}
+}
+
+func frequencyFromGaps(gaps map[int]int) string {
+	if len(gaps) == 0 {
+		return FrequencyUnknown
+	}
+
+	modal, best := 0, 0
+	for gap, count := range gaps {
+		if count > best || count == best && gap < modal {
+			modal, best = gap, count
+		}
+	}
+
+	switch modal {
+	case 7:
+		return FrequencyWeekly
+	case 14:
+		return FrequencyFortnightly
+	default:
+		return FrequencyIrregular
+	}
 }
 
 type blockDefinition struct {
-	blockSelector string
-	entrySelector string
-	daySelector   string
-	monthSelector string
-	wasteType     string
+	blockSelector   string
+	entrySelector   string
+	daySelector     string
+	monthSelector   string
+	weekdaySelector string
+	wasteType       string
 }
 
 func normalizeSpaces(value string) string {
 	return strings.Join(strings.Fields(value), " ")
 }
 
+// GardenStatus reports the garden-waste service state on the most recently
+// parsed schedule page, or GardenUnknown before the first.
+func (s *Scraper) GardenStatus() GardenStatus {
+	if status, ok := s.garden.Load().(GardenStatus); ok {
+		return status
+	}
+	return GardenUnknown
+}
+
+// classifyGarden tells an unsubscribed address from a seasonal suspension,
+// which otherwise look the same: no garden-waste dates. The council omits
+// the garden block for addresses without the paid service, or shows a
+// notice asking them to subscribe; out of season it shows a resume notice.
+func classifyGarden(doc *goquery.Document) GardenStatus {
+	container := doc.Find(".your-collection-schedule-container").First()
+	if container.Length() == 0 {
+		return GardenUnknown
+	}
+	block := container.Find(".garden-container").First()
+	if block.Length() == 0 {
+		return GardenNotSubscribed
+	}
+	if block.Find(".collectionDates-container .garden-collection-postdate").Length() > 0 {
+		return GardenActive
+	}
+
+	notice := extractGardenNotice(block)
+	if notice == "" {
+		notice = normalizeSpaces(block.Text())
+	}
+	notice = strings.ToLower(notice)
+	for _, word := range gardenSuspendedWords {
+		if strings.Contains(notice, word) {
+			return GardenSuspended
+		}
+	}
+	for _, word := range gardenNotSubscribedWords {
+		if strings.Contains(notice, word) {
+			return GardenNotSubscribed
+		}
+	}
+	return GardenUnknown
+}
+
 func extractGardenNotice(block *goquery.Selection) string {
 	notice := normalizeSpaces(block.Find(".collectionDates-container .upcoming-dates").First().Text())
 	return notice
@@ -455,6 +1115,16 @@ func extractNoteText(sel *goquery.Selection, def blockDefinition) string {
 	return strings.Join(notes, " ")
 }
 
+// skippedEntry turns a cancelled entry's type and note into their skipped
+// form, falling back to a generic note when the council gives no reason.
+func skippedEntry(wasteType, note string) (string, string) {
+	note = strings.Trim(note, " -–—:")
+	if note == "" {
+		note = defaultSkippedNote
+	}
+	return wasteType + NoCollectionSuffix, note
+}
+
 func cloneInstructions(values []Instruction) []Instruction {
 	if len(values) == 0 {
 		return nil
@@ -1,14 +1,19 @@
 package scraper
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/PuerkitoBio/goquery"
 )
 
 func TestFetchCollectionsSuccess(t *testing.T) {
@@ -57,6 +62,9 @@ func TestFetchCollectionsSuccess(t *testing.T) {
 	if first.Type != "Refuse" {
 		t.Fatalf("expected first type Refuse, got %s", first.Type)
 	}
+	if first.Source != SourcePrimary {
+		t.Fatalf("expected primary source, got %q", first.Source)
+	}
 
 	foundGarden := 0
 	foundFood := 0
@@ -110,16 +118,122 @@ func TestFetchCollectionsSuccess(t *testing.T) {
 	}
 }
 
-func TestFetchCollectionsSaveAddressFailure(t *testing.T) {
+func TestFetchCollectionsThroughProxy(t *testing.T) {
+	html := loadFixture(t, "testdata/schedule.html")
+
 	mux := http.NewServeMux()
 	mux.HandleFunc("/Shared/SaveAddress", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusInternalServerError)
+		http.SetCookie(w, &http.Cookie{Name: "RedbridgeIV3LivePref", Value: "abc"})
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/RecycleRefuse", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(html))
+	})
+	origin := httptest.NewServer(mux)
+	defer origin.Close()
+
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.Path)
+		out := r.Clone(r.Context())
+		out.RequestURI = ""
+		resp, err := http.DefaultTransport.RoundTrip(out)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		for k, v := range resp.Header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
+	}))
+	defer proxy.Close()
+
+	s, err := New(Config{
+		BaseURL:        origin.URL,
+		SchedulePath:   "/RecycleRefuse",
+		UPRN:           "123",
+		StartHour:      6,
+		RequestTimeout: time.Second,
+		RequestDelay:   time.Millisecond,
+		Timezone:       "Europe/London",
+		ProxyURL:       proxy.URL,
+	})
+	if err != nil {
+		t.Fatalf("New scraper: %v", err)
+	}
+
+	if _, err := s.FetchCollections(context.Background()); err != nil {
+		t.Fatalf("FetchCollections: %v", err)
+	}
+	if len(proxied) != 2 || proxied[0] != "/Shared/SaveAddress" || proxied[1] != "/RecycleRefuse" {
+		t.Fatalf("expected both requests through the proxy, got %v", proxied)
+	}
+
+	for _, bad := range []string{"ftp://proxy:21", "proxy.internal:3128"} {
+		if _, err := New(Config{UPRN: "123", Timezone: "Europe/London", ProxyURL: bad}); err == nil {
+			t.Fatalf("expected error for proxy URL %q", bad)
+		}
+	}
+}
+
+func TestNewConnectionPool(t *testing.T) {
+	s, err := New(Config{BaseURL: "http://origin", SchedulePath: "/RecycleRefuse", UPRN: "123", Timezone: "Europe/London"})
+	if err != nil {
+		t.Fatalf("New scraper: %v", err)
+	}
+	transport := s.client.Transport.(*http.Transport)
+	if transport.MaxIdleConns != 100 || transport.MaxIdleConnsPerHost != 4 || transport.IdleConnTimeout != 90*time.Second {
+		t.Fatalf("unexpected default pool %d/%d/%s", transport.MaxIdleConns, transport.MaxIdleConnsPerHost, transport.IdleConnTimeout)
+	}
+
+	s, err = New(Config{
+		BaseURL:             "http://origin",
+		SchedulePath:        "/RecycleRefuse",
+		UPRN:                "123",
+		Timezone:            "Europe/London",
+		MaxIdleConns:        50,
+		MaxIdleConnsPerHost: 16,
+		IdleConnTimeout:     30 * time.Second,
 	})
+	if err != nil {
+		t.Fatalf("New scraper: %v", err)
+	}
+	transport = s.client.Transport.(*http.Transport)
+	if transport.MaxIdleConns != 50 || transport.MaxIdleConnsPerHost != 16 || transport.IdleConnTimeout != 30*time.Second {
+		t.Fatalf("unexpected pool %d/%d/%s", transport.MaxIdleConns, transport.MaxIdleConnsPerHost, transport.IdleConnTimeout)
+	}
+}
+
+func TestFetchCollectionsBasicAuth(t *testing.T) {
+	html := loadFixture(t, "testdata/schedule.html")
+
+	requireAuth := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			user, pass, ok := r.BasicAuth()
+			if !ok || user != "staging" || pass != "s3cret" {
+				w.Header().Set("WWW-Authenticate", `Basic realm="staging"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next(w, r)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/Shared/SaveAddress", requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "RedbridgeIV3LivePref", Value: "abc"})
+		w.WriteHeader(http.StatusOK)
+	}))
+	mux.HandleFunc("/RecycleRefuse", requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(html))
+	}))
 
 	ts := httptest.NewServer(mux)
 	defer ts.Close()
 
-	s, err := New(Config{
+	cfg := Config{
 		BaseURL:        ts.URL,
 		SchedulePath:   "/RecycleRefuse",
 		UPRN:           "123",
@@ -127,6 +241,59 @@ func TestFetchCollectionsSaveAddressFailure(t *testing.T) {
 		StartHour:      6,
 		RequestTimeout: time.Second,
 		Timezone:       "Europe/London",
+		OriginUsername: "staging",
+		OriginPassword: "s3cret",
+	}
+	s, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New scraper: %v", err)
+	}
+	s.client = ts.Client()
+
+	collections, err := s.FetchCollections(context.Background())
+	if err != nil {
+		t.Fatalf("FetchCollections: %v", err)
+	}
+	if len(collections) != 7 {
+		t.Fatalf("expected 7 collections, got %d", len(collections))
+	}
+
+	cfg.OriginPassword = "wrong"
+	s, err = New(cfg)
+	if err != nil {
+		t.Fatalf("New scraper: %v", err)
+	}
+	s.client = ts.Client()
+	if _, err := s.FetchCollections(context.Background()); !errors.Is(err, ErrAddressSetup) {
+		t.Fatalf("expected ErrAddressSetup with bad credentials, got %v", err)
+	}
+}
+
+func TestFetchCollectionsBodyTooLarge(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/Shared/SaveAddress", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "RedbridgeIV3LivePref", Value: "abc"})
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/RecycleRefuse", func(w http.ResponseWriter, r *http.Request) {
+		chunk := []byte(strings.Repeat("<p>spam</p>", 100))
+		for i := 0; i < 100; i++ {
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+		}
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	s, err := New(Config{
+		BaseURL:        ts.URL,
+		SchedulePath:   "/RecycleRefuse",
+		UPRN:           "123",
+		RequestTimeout: time.Second,
+		RequestDelay:   time.Millisecond,
+		Timezone:       "Europe/London",
+		MaxBodyBytes:   4096,
 	})
 	if err != nil {
 		t.Fatalf("New scraper: %v", err)
@@ -134,21 +301,26 @@ func TestFetchCollectionsSaveAddressFailure(t *testing.T) {
 	s.client = ts.Client()
 
 	_, err = s.FetchCollections(context.Background())
-	if err == nil || !strings.Contains(err.Error(), "address") {
-		t.Fatalf("expected address error, got %v", err)
+	if !errors.Is(err, ErrBodyTooLarge) {
+		t.Fatalf("expected ErrBodyTooLarge, got %v", err)
 	}
 }
 
-func TestFetchCollectionsGardenNotice(t *testing.T) {
-	html := loadFixture(t, "testdata/schedule_garden_missing.html")
-	notice := "The fortnightly Garden Waste Collection Service will resume in the Spring"
+func TestFetchCollectionsRetriesTransientErrors(t *testing.T) {
+	html := loadFixture(t, "testdata/schedule.html")
 
+	scheduleCalls := 0
 	mux := http.NewServeMux()
 	mux.HandleFunc("/Shared/SaveAddress", func(w http.ResponseWriter, r *http.Request) {
 		http.SetCookie(w, &http.Cookie{Name: "RedbridgeIV3LivePref", Value: "abc"})
 		w.WriteHeader(http.StatusOK)
 	})
 	mux.HandleFunc("/RecycleRefuse", func(w http.ResponseWriter, r *http.Request) {
+		scheduleCalls++
+		if scheduleCalls <= 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte(html))
 	})
@@ -164,6 +336,8 @@ func TestFetchCollectionsGardenNotice(t *testing.T) {
 		StartHour:      6,
 		RequestTimeout: time.Second,
 		Timezone:       "Europe/London",
+		MaxRetries:     2,
+		RetryBaseDelay: time.Millisecond,
 	})
 	if err != nil {
 		t.Fatalf("New scraper: %v", err)
@@ -174,114 +348,113 @@ func TestFetchCollectionsGardenNotice(t *testing.T) {
 	if err != nil {
 		t.Fatalf("FetchCollections: %v", err)
 	}
-	if len(collections) != 2 {
-		t.Fatalf("expected 2 collections, got %d", len(collections))
+	if scheduleCalls != 3 {
+		t.Fatalf("expected 3 schedule attempts, got %d", scheduleCalls)
 	}
-	for _, c := range collections {
-		if c.Type == "Garden Waste" {
-			t.Fatalf("did not expect garden waste collections")
-		}
-		if !strings.Contains(c.Note, notice) {
-			t.Fatalf("expected garden notice in %s note, got %q", c.Type, c.Note)
-		}
+	if len(collections) == 0 {
+		t.Fatalf("expected collections after retry")
 	}
 }
 
-func TestParseCollectionsCurrentGardenMarkup(t *testing.T) {
-	html := `<div class="your-collection-schedule-container">
-  <div class="garden-container">
-    <div class="collectionType">
-      <h3>Garden Waste</h3>
-      <p></p>
-    </div>
-    <p class="upcoming-dates">Upcoming collection dates:</p>
-    <div class="collectionDates-container bs3-col-sm-12">
-      <div class="garden-collection-postdate bs3-col-sm-2">
-        <div class="garden-collection-day-of-week">Wednesday</div>
-        <div class="garden-garden-collection-day-numeric">24</div>
-        <div class="garden-collection-month">June 2026</div>
-      </div>
-      <div class="garden-collection-postdate bs3-col-sm-2">
-        <div class="garden-collection-day-of-week">Wednesday</div>
-        <div class="garden-garden-collection-day-numeric">08</div>
-        <div class="garden-collection-month">July 2026</div>
-      </div>
-      <div class="garden-collection-postdate bs3-col-sm-2">
-        <div class="garden-collection-day-of-week">Wednesday</div>
-        <div class="garden-garden-collection-day-numeric">22</div>
-        <div class="garden-collection-month">July 2026</div>
-      </div>
-      <div class="garden-collection-postdate bs3-col-sm-2">
-        <div class="garden-collection-day-of-week">Wednesday</div>
-        <div class="garden-garden-collection-day-numeric">05</div>
-        <div class="garden-collection-month">August 2026</div>
-      </div>
-      <div class="garden-collection-postdate bs3-col-sm-2">
-        <div class="garden-collection-day-of-week">Wednesday</div>
-        <div class="garden-garden-collection-day-numeric">19</div>
-        <div class="garden-collection-month">August 2026</div>
-      </div>
-    </div>
-    <div class="collectionDetail bs3-col-sm-12">
-      <p class="instructions smalltext muted">
-        Please place your garden waste at the boundary of your property by <strong>6.00am</strong>.<br>
-        We will collect up to <strong>5 sacks.</strong> Leave all sacks open so the waste can be seen.<br>
-        You can use Green Garden Waste sacks, black bin bags or clear sacks.
-      </p>
-      <p class="instructions smalltext muted">
-        <span class="missed-text">Missed collection?</span>
-        <a href="/MissedCollection/garden" class="redbridge-link">Report missed garden waste collection</a>
-      </p>
-    </div>
-  </div>
-</div>`
+func TestFetchCollectionsDoesNotRetryClientErrors(t *testing.T) {
+	scheduleCalls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/Shared/SaveAddress", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "RedbridgeIV3LivePref", Value: "abc"})
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/RecycleRefuse", func(w http.ResponseWriter, r *http.Request) {
+		scheduleCalls++
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
 
 	s, err := New(Config{
-		BaseURL:        "https://www.redbridge.gov.uk",
+		BaseURL:        ts.URL,
 		SchedulePath:   "/RecycleRefuse",
 		UPRN:           "123",
+		UserAgent:      "test-agent",
 		StartHour:      6,
 		RequestTimeout: time.Second,
 		Timezone:       "Europe/London",
+		MaxRetries:     2,
+		RetryBaseDelay: time.Millisecond,
 	})
 	if err != nil {
 		t.Fatalf("New scraper: %v", err)
 	}
+	s.client = ts.Client()
 
-	collections, err := s.parseCollections([]byte(html))
-	if err != nil {
-		t.Fatalf("parseCollections: %v", err)
+	if _, err := s.FetchCollections(context.Background()); err == nil {
+		t.Fatalf("expected error for 404 schedule")
 	}
-	if len(collections) != 5 {
-		t.Fatalf("expected 5 garden collections, got %d", len(collections))
+	if scheduleCalls != 1 {
+		t.Fatalf("expected 4xx not to be retried, got %d attempts", scheduleCalls)
 	}
+}
 
-	first := collections[0]
-	if first.Type != "Garden Waste" {
-		t.Fatalf("expected Garden Waste, got %s", first.Type)
+func TestFetchCollectionsFrequency(t *testing.T) {
+	html := loadFixture(t, "testdata/schedule_cadence.html")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/Shared/SaveAddress", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "RedbridgeIV3LivePref", Value: "abc"})
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/RecycleRefuse", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(html))
+	})
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	s, err := New(Config{
+		BaseURL:        ts.URL,
+		SchedulePath:   "/RecycleRefuse",
+		UPRN:           "123",
+		UserAgent:      "test-agent",
+		StartHour:      6,
+		RequestTimeout: time.Second,
+		Timezone:       "Europe/London",
+	})
+	if err != nil {
+		t.Fatalf("New scraper: %v", err)
 	}
-	expectedDate := time.Date(2026, time.June, 24, 6, 0, 0, 0, s.location)
-	if !first.Date.Equal(expectedDate) {
-		t.Fatalf("expected first date %s, got %s", expectedDate, first.Date)
+	s.client = ts.Client()
+
+	collections, err := s.FetchCollections(context.Background())
+	if err != nil {
+		t.Fatalf("FetchCollections: %v", err)
 	}
-	if len(first.Instructions) != 2 {
-		t.Fatalf("expected 2 garden instructions, got %d", len(first.Instructions))
+
+	expected := map[string]string{
+		"Refuse":       FrequencyWeekly,
+		"Recycling":    FrequencyFortnightly,
+		"Garden Waste": FrequencyIrregular,
+		"Food Waste":   FrequencyUnknown,
 	}
-	expectedLink := "https://www.redbridge.gov.uk/MissedCollection/garden"
-	if len(first.Instructions[1].Links) != 1 || first.Instructions[1].Links[0] != expectedLink {
-		t.Fatalf("unexpected garden missed collection link: %v", first.Instructions[1].Links)
+	for _, c := range collections {
+		if want := expected[c.Type]; c.Frequency != want {
+			t.Fatalf("expected %s frequency %q, got %q", c.Type, want, c.Frequency)
+		}
 	}
 }
 
-func TestFetchCollectionsSaveAddressFailureWithCookie(t *testing.T) {
+func TestFetchCollectionsRotatesUserAgents(t *testing.T) {
 	html := loadFixture(t, "testdata/schedule.html")
 
+	var seedAgents, scheduleAgents []string
 	mux := http.NewServeMux()
 	mux.HandleFunc("/Shared/SaveAddress", func(w http.ResponseWriter, r *http.Request) {
+		seedAgents = append(seedAgents, r.Header.Get("User-Agent"))
 		http.SetCookie(w, &http.Cookie{Name: "RedbridgeIV3LivePref", Value: "abc"})
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(http.StatusOK)
 	})
 	mux.HandleFunc("/RecycleRefuse", func(w http.ResponseWriter, r *http.Request) {
+		scheduleAgents = append(scheduleAgents, r.Header.Get("User-Agent"))
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte(html))
 	})
@@ -294,6 +467,7 @@ func TestFetchCollectionsSaveAddressFailureWithCookie(t *testing.T) {
 		SchedulePath:   "/RecycleRefuse",
 		UPRN:           "123",
 		UserAgent:      "test-agent",
+		UserAgents:     []string{"agent-a", "agent-b"},
 		StartHour:      6,
 		RequestTimeout: time.Second,
 		Timezone:       "Europe/London",
@@ -303,18 +477,24 @@ func TestFetchCollectionsSaveAddressFailureWithCookie(t *testing.T) {
 	}
 	s.client = ts.Client()
 
-	if _, err := s.FetchCollections(context.Background()); err != nil {
-		t.Fatalf("FetchCollections: %v", err)
+	for i := 0; i < 3; i++ {
+		if _, err := s.FetchCollections(context.Background()); err != nil {
+			t.Fatalf("FetchCollections: %v", err)
+		}
+	}
+
+	expected := []string{"agent-a", "agent-b", "agent-a"}
+	for i, want := range expected {
+		if seedAgents[i] != want || scheduleAgents[i] != want {
+			t.Fatalf("fetch %d: expected %s, got seed=%s schedule=%s", i, want, seedAgents[i], scheduleAgents[i])
+		}
 	}
 }
 
-func TestFetchCollectionsNoCollections(t *testing.T) {
+func TestFetchCollectionsSaveAddressFailure(t *testing.T) {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/Shared/SaveAddress", func(w http.ResponseWriter, r *http.Request) {
-		http.SetCookie(w, &http.Cookie{Name: "RedbridgeIV3LivePref", Value: "abc"})
-	})
-	mux.HandleFunc("/RecycleRefuse", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
+		w.WriteHeader(http.StatusInternalServerError)
 	})
 
 	ts := httptest.NewServer(mux)
@@ -335,8 +515,957 @@ func TestFetchCollectionsNoCollections(t *testing.T) {
 	s.client = ts.Client()
 
 	_, err = s.FetchCollections(context.Background())
-	if !errors.Is(err, ErrNoCollections) {
-		t.Fatalf("expected ErrNoCollections, got %v", err)
+	if !errors.Is(err, ErrAddressSetup) {
+		t.Fatalf("expected ErrAddressSetup, got %v", err)
+	}
+}
+
+func TestFetchCollectionsQueryFallback(t *testing.T) {
+	html := loadFixture(t, "testdata/schedule.html")
+
+	var scheduleQueries []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/Shared/SaveAddress", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/RecycleRefuse", func(w http.ResponseWriter, r *http.Request) {
+		scheduleQueries = append(scheduleQueries, r.URL.RawQuery)
+		if r.URL.Query().Get("uprn") != "123" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		_, _ = w.Write([]byte(html))
+	})
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	s, err := New(Config{
+		BaseURL:        ts.URL,
+		SchedulePath:   "/RecycleRefuse",
+		UPRN:           "123",
+		UserAgent:      "test-agent",
+		StartHour:      6,
+		RequestTimeout: time.Second,
+		Timezone:       "Europe/London",
+	})
+	if err != nil {
+		t.Fatalf("New scraper: %v", err)
+	}
+	s.client = ts.Client()
+
+	collections, err := s.FetchCollections(context.Background())
+	if err != nil {
+		t.Fatalf("FetchCollections: %v", err)
+	}
+	if len(collections) != 7 {
+		t.Fatalf("expected 7 collections, got %d", len(collections))
+	}
+	if len(scheduleQueries) != 1 || scheduleQueries[0] != "uprn=123" {
+		t.Fatalf("expected a single schedule fetch with uprn=123, got %v", scheduleQueries)
+	}
+}
+
+func TestValidateAddress(t *testing.T) {
+	var schedule int
+	setCookie := true
+	mux := http.NewServeMux()
+	mux.HandleFunc("/Shared/SaveAddress", func(w http.ResponseWriter, r *http.Request) {
+		if setCookie {
+			http.SetCookie(w, &http.Cookie{Name: "RedbridgeIV3LivePref", Value: "abc"})
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/RecycleRefuse", func(w http.ResponseWriter, r *http.Request) {
+		schedule++
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	s, err := New(Config{
+		BaseURL:        ts.URL,
+		SchedulePath:   "/RecycleRefuse",
+		UPRN:           "123",
+		RequestTimeout: time.Second,
+		Timezone:       "Europe/London",
+	})
+	if err != nil {
+		t.Fatalf("New scraper: %v", err)
+	}
+	s.client = ts.Client()
+
+	if err := s.ValidateAddress(context.Background()); err != nil {
+		t.Fatalf("ValidateAddress: %v", err)
+	}
+	setCookie = false
+	if err := s.ValidateAddress(context.Background()); !errors.Is(err, ErrAddressSetup) {
+		t.Fatalf("expected ErrAddressSetup without the cookie, got %v", err)
+	}
+	if schedule != 0 {
+		t.Fatalf("expected no schedule requests, got %d", schedule)
+	}
+}
+
+func TestFetchCollectionsSaveAddressPost(t *testing.T) {
+	html := loadFixture(t, "testdata/schedule.html")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /Shared/SaveAddress", func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/x-www-form-urlencoded" {
+			t.Errorf("unexpected content type %q", ct)
+		}
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("uprn") != "123" || r.PostForm.Get("postcode") != "IG1 1AA" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "RedbridgeIV3LivePref", Value: "abc", Path: "/"})
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/RecycleRefuse", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Cookie("RedbridgeIV3LivePref"); err != nil {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		_, _ = w.Write([]byte(html))
+	})
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	cfg := Config{
+		BaseURL:           ts.URL,
+		SchedulePath:      "/RecycleRefuse",
+		UPRN:              "123",
+		Postcode:          "IG1 1AA",
+		UserAgent:         "test-agent",
+		StartHour:         6,
+		RequestTimeout:    time.Second,
+		Timezone:          "Europe/London",
+		SaveAddressMethod: "post",
+	}
+	s, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New scraper: %v", err)
+	}
+	s.client = ts.Client()
+
+	collections, err := s.FetchCollections(context.Background())
+	if err != nil {
+		t.Fatalf("FetchCollections: %v", err)
+	}
+	if len(collections) != 7 {
+		t.Fatalf("expected 7 collections, got %d", len(collections))
+	}
+
+	cfg.SaveAddressMethod = "PUT"
+	if _, err := New(cfg); err == nil {
+		t.Fatalf("expected error for SaveAddress method PUT")
+	}
+}
+
+func TestFetchCollectionsGardenNotice(t *testing.T) {
+	html := loadFixture(t, "testdata/schedule_garden_missing.html")
+	notice := "The fortnightly Garden Waste Collection Service will resume in the Spring"
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/Shared/SaveAddress", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "RedbridgeIV3LivePref", Value: "abc"})
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/RecycleRefuse", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(html))
+	})
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	s, err := New(Config{
+		BaseURL:        ts.URL,
+		SchedulePath:   "/RecycleRefuse",
+		UPRN:           "123",
+		UserAgent:      "test-agent",
+		StartHour:      6,
+		RequestTimeout: time.Second,
+		Timezone:       "Europe/London",
+	})
+	if err != nil {
+		t.Fatalf("New scraper: %v", err)
+	}
+	s.client = ts.Client()
+
+	collections, err := s.FetchCollections(context.Background())
+	if err != nil {
+		t.Fatalf("FetchCollections: %v", err)
+	}
+	if len(collections) != 2 {
+		t.Fatalf("expected 2 collections, got %d", len(collections))
+	}
+	for _, c := range collections {
+		if c.Type == "Garden Waste" {
+			t.Fatalf("did not expect garden waste collections")
+		}
+		if !strings.Contains(c.Note, notice) {
+			t.Fatalf("expected garden notice in %s note, got %q", c.Type, c.Note)
+		}
+	}
+}
+
+func TestParseCollectionsGardenStatus(t *testing.T) {
+	s, err := New(Config{
+		BaseURL:      "https://my.redbridge.gov.uk",
+		SchedulePath: "/RecycleRefuse",
+		UPRN:         "123",
+		StartHour:    6,
+		Timezone:     "Europe/London",
+	})
+	if err != nil {
+		t.Fatalf("New scraper: %v", err)
+	}
+	if got := s.GardenStatus(); got != GardenUnknown {
+		t.Fatalf("expected unknown before parsing, got %q", got)
+	}
+
+	cases := []struct {
+		fixture string
+		want    GardenStatus
+	}{
+		{"testdata/schedule.html", GardenActive},
+		{"testdata/schedule_garden_missing.html", GardenSuspended},
+		{"testdata/schedule_garden_not_subscribed.html", GardenNotSubscribed},
+		{"testdata/schedule_garden_absent.html", GardenNotSubscribed},
+		{"testdata/schedule_sections.html", GardenUnknown},
+	}
+	for _, tc := range cases {
+		if _, err := s.collectionsFrom([]byte(loadFixture(t, tc.fixture))); err != nil {
+			t.Fatalf("%s: collectionsFrom: %v", tc.fixture, err)
+		}
+		if got := s.GardenStatus(); got != tc.want {
+			t.Fatalf("%s: GardenStatus = %q, want %q", tc.fixture, got, tc.want)
+		}
+	}
+
+	unrecognised := `<div class="your-collection-schedule-container">
+  <div class="refuse-container">
+    <div class="collectionDates-container">
+      <div class="garden-collection-postdate">
+        <span class="refuse-garden-collection-day-numeric">02</span>
+        <span class="refuse-collection-month">December 2025</span>
+      </div>
+    </div>
+  </div>
+  <div class="garden-container">
+    <div class="collectionDates-container"><p class="upcoming-dates">Please check back later.</p></div>
+  </div>
+</div>`
+	if _, err := s.collectionsFrom([]byte(unrecognised)); err != nil {
+		t.Fatalf("collectionsFrom: %v", err)
+	}
+	if got := s.GardenStatus(); got != GardenUnknown {
+		t.Fatalf("expected unknown for an unrecognised notice, got %q", got)
+	}
+}
+
+func TestParseCollectionsCurrentGardenMarkup(t *testing.T) {
+	html := `<div class="your-collection-schedule-container">
+  <div class="garden-container">
+    <div class="collectionType">
+      <h3>Garden Waste</h3>
+      <p></p>
+    </div>
+    <p class="upcoming-dates">Upcoming collection dates:</p>
+    <div class="collectionDates-container bs3-col-sm-12">
+      <div class="garden-collection-postdate bs3-col-sm-2">
+        <div class="garden-collection-day-of-week">Wednesday</div>
+        <div class="garden-garden-collection-day-numeric">24</div>
+        <div class="garden-collection-month">June 2026</div>
+      </div>
+      <div class="garden-collection-postdate bs3-col-sm-2">
+        <div class="garden-collection-day-of-week">Wednesday</div>
+        <div class="garden-garden-collection-day-numeric">08</div>
+        <div class="garden-collection-month">July 2026</div>
+      </div>
+      <div class="garden-collection-postdate bs3-col-sm-2">
+        <div class="garden-collection-day-of-week">Wednesday</div>
+        <div class="garden-garden-collection-day-numeric">22</div>
+        <div class="garden-collection-month">July 2026</div>
+      </div>
+      <div class="garden-collection-postdate bs3-col-sm-2">
+        <div class="garden-collection-day-of-week">Wednesday</div>
+        <div class="garden-garden-collection-day-numeric">05</div>
+        <div class="garden-collection-month">August 2026</div>
+      </div>
+      <div class="garden-collection-postdate bs3-col-sm-2">
+        <div class="garden-collection-day-of-week">Wednesday</div>
+        <div class="garden-garden-collection-day-numeric">19</div>
+        <div class="garden-collection-month">August 2026</div>
+      </div>
+    </div>
+    <div class="collectionDetail bs3-col-sm-12">
+      <p class="instructions smalltext muted">
+        Please place your garden waste at the boundary of your property by <strong>6.00am</strong>.<br>
+        We will collect up to <strong>5 sacks.</strong> Leave all sacks open so the waste can be seen.<br>
+        You can use Green Garden Waste sacks, black bin bags or clear sacks.
+      </p>
+      <p class="instructions smalltext muted">
+        <span class="missed-text">Missed collection?</span>
+        <a href="/MissedCollection/garden" class="redbridge-link">Report missed garden waste collection</a>
+      </p>
+    </div>
+  </div>
+</div>`
+
+	s, err := New(Config{
+		BaseURL:        "https://www.redbridge.gov.uk",
+		SchedulePath:   "/RecycleRefuse",
+		UPRN:           "123",
+		StartHour:      6,
+		RequestTimeout: time.Second,
+		Timezone:       "Europe/London",
+	})
+	if err != nil {
+		t.Fatalf("New scraper: %v", err)
+	}
+
+	collections, err := s.parseCollections([]byte(html))
+	if err != nil {
+		t.Fatalf("parseCollections: %v", err)
+	}
+	if len(collections) != 5 {
+		t.Fatalf("expected 5 garden collections, got %d", len(collections))
+	}
+
+	first := collections[0]
+	if first.Type != "Garden Waste" {
+		t.Fatalf("expected Garden Waste, got %s", first.Type)
+	}
+	expectedDate := time.Date(2026, time.June, 24, 6, 0, 0, 0, s.location)
+	if !first.Date.Equal(expectedDate) {
+		t.Fatalf("expected first date %s, got %s", expectedDate, first.Date)
+	}
+	if len(first.Instructions) != 2 {
+		t.Fatalf("expected 2 garden instructions, got %d", len(first.Instructions))
+	}
+	expectedLink := "https://www.redbridge.gov.uk/MissedCollection/garden"
+	if len(first.Instructions[1].Links) != 1 || first.Instructions[1].Links[0] != expectedLink {
+		t.Fatalf("unexpected garden missed collection link: %v", first.Instructions[1].Links)
+	}
+}
+
+func TestParseCollectionsFoodContainerMarkup(t *testing.T) {
+	html := `<div class="your-collection-schedule-container">
+  <div class="refuse-container">
+    <div class="collectionDates-container">
+      <div class="garden-collection-postdate">
+        <span class="refuse-garden-collection-day-numeric">02</span>
+        <span class="refuse-collection-month">December 2025</span>
+      </div>
+    </div>
+  </div>
+  <div class="food-container">
+    <div class="collectionDates-container">
+      <div class="garden-collection-postdate">
+        <div class="food-collection-day-of-week">Tuesday</div>
+        <div class="food-garden-collection-day-numeric">02</div>
+        <div class="food-collection-month">December 2025</div>
+      </div>
+      <div class="garden-collection-postdate">
+        <div class="food-collection-day-of-week">Tuesday</div>
+        <div class="food-garden-collection-day-numeric">02</div>
+        <div class="food-collection-month">December 2025</div>
+      </div>
+      <div class="garden-collection-postdate">
+        <div class="food-collection-day-of-week">Tuesday</div>
+        <div class="food-garden-collection-day-numeric">09</div>
+        <div class="food-collection-month">December 2025</div>
+      </div>
+    </div>
+    <div class="collectionDetail bs3-col-sm-12">
+      <p class="instructions smalltext muted">
+        Please place your outside food waste caddy at the boundary of your property by <strong>6.00am</strong> on your collection day.
+      </p>
+      <p class="instructions smalltext muted">
+        <span class="missed-text">Missed collection?</span>
+        <a href="/MissedCollection/foodwaste" class="redbridge-link">Report missed food waste collection</a>
+      </p>
+    </div>
+  </div>
+</div>`
+
+	s, err := New(Config{
+		BaseURL:        "https://my.redbridge.gov.uk",
+		SchedulePath:   "/RecycleRefuse",
+		UPRN:           "123",
+		StartHour:      6,
+		RequestTimeout: time.Second,
+		Timezone:       "Europe/London",
+	})
+	if err != nil {
+		t.Fatalf("New scraper: %v", err)
+	}
+
+	collections, err := s.parseCollections([]byte(html))
+	if err != nil {
+		t.Fatalf("parseCollections: %v", err)
+	}
+
+	food := 0
+	for _, c := range collections {
+		if c.Type != "Food Waste" {
+			continue
+		}
+		food++
+		if len(c.Instructions) != 2 {
+			t.Fatalf("expected 2 food instructions, got %d", len(c.Instructions))
+		}
+	}
+	if food != 2 {
+		t.Fatalf("expected 2 deduplicated food collections, got %d", food)
+	}
+	if len(collections) != 3 {
+		t.Fatalf("expected 3 collections, got %d", len(collections))
+	}
+}
+
+func TestParseCollectionsStartHourByType(t *testing.T) {
+	html := loadFixture(t, "testdata/schedule.html")
+
+	s, err := New(Config{
+		BaseURL:         "https://my.redbridge.gov.uk",
+		SchedulePath:    "/RecycleRefuse",
+		UPRN:            "123",
+		StartHour:       6,
+		StartHourByType: map[string]int{"food waste": 5},
+		RequestTimeout:  time.Second,
+		Timezone:        "Europe/London",
+	})
+	if err != nil {
+		t.Fatalf("New scraper: %v", err)
+	}
+
+	collections, err := s.parseCollections([]byte(html))
+	if err != nil {
+		t.Fatalf("parseCollections: %v", err)
+	}
+
+	var sawFood, sawRefuse bool
+	for _, c := range collections {
+		switch c.Type {
+		case "Food Waste":
+			sawFood = true
+			if c.Date.Hour() != 5 {
+				t.Fatalf("expected food waste at 05:00, got %s", c.Date)
+			}
+		case "Refuse":
+			sawRefuse = true
+			if c.Date.Hour() != 6 {
+				t.Fatalf("expected refuse at default 06:00, got %s", c.Date)
+			}
+		}
+	}
+	if !sawFood || !sawRefuse {
+		t.Fatalf("expected both food and refuse collections, got %+v", collections)
+	}
+}
+
+func TestParseCollectionsFallbackLayout(t *testing.T) {
+	html := loadFixture(t, "testdata/schedule_sections.html")
+
+	s, err := New(Config{
+		BaseURL:        "https://my.redbridge.gov.uk",
+		SchedulePath:   "/RecycleRefuse",
+		UPRN:           "123",
+		StartHour:      6,
+		RequestTimeout: time.Second,
+		Timezone:       "Europe/London",
+	})
+	if err != nil {
+		t.Fatalf("New scraper: %v", err)
+	}
+
+	collections, err := s.parseCollections([]byte(html))
+	if err != nil {
+		t.Fatalf("parseCollections: %v", err)
+	}
+	if len(collections) != 5 {
+		t.Fatalf("expected 5 collections from fallback layout, got %d", len(collections))
+	}
+
+	counts := map[string]int{}
+	for _, c := range collections {
+		counts[c.Type]++
+		if c.Date.Hour() != 6 {
+			t.Fatalf("expected start hour 6, got %s", c.Date)
+		}
+		if c.Source != SourceFallback {
+			t.Fatalf("expected fallback source, got %q", c.Source)
+		}
+	}
+	if counts["Refuse"] != 2 || counts["Recycling"] != 2 || counts["Food Waste"] != 1 {
+		t.Fatalf("unexpected type counts: %v", counts)
+	}
+	for _, c := range collections {
+		if c.Type == "Recycling" && (len(c.Instructions) != 1 || c.Instructions[0].Text != "Rinse containers before recycling.") {
+			t.Fatalf("expected recycling instructions, got %+v", c.Instructions)
+		}
+	}
+}
+
+func TestParseCollectionsSkippedWeek(t *testing.T) {
+	html := loadFixture(t, "testdata/schedule_skipped.html")
+
+	s, err := New(Config{
+		BaseURL:      "https://my.redbridge.gov.uk",
+		SchedulePath: "/RecycleRefuse",
+		UPRN:         "123",
+		StartHour:    6,
+		Timezone:     "Europe/London",
+	})
+	if err != nil {
+		t.Fatalf("New scraper: %v", err)
+	}
+
+	collections, err := s.parseCollections([]byte(html))
+	if err != nil {
+		t.Fatalf("parseCollections: %v", err)
+	}
+	if len(collections) != 5 {
+		t.Fatalf("expected 5 entries, got %d: %+v", len(collections), collections)
+	}
+
+	skipped := map[string]Collection{}
+	for _, c := range collections {
+		if c.Skipped() {
+			skipped[c.Type] = c
+		}
+	}
+	if len(skipped) != 2 {
+		t.Fatalf("expected 2 skipped entries, got %+v", skipped)
+	}
+
+	refuse, ok := skipped["Refuse"+NoCollectionSuffix]
+	if !ok {
+		t.Fatalf("expected skipped refuse entry, got %+v", skipped)
+	}
+	if refuse.Date.Day() != 30 || refuse.Date.Month() != time.December {
+		t.Fatalf("unexpected skipped refuse date %s", refuse.Date)
+	}
+	if refuse.Note != "No collection due to bank holiday." {
+		t.Fatalf("unexpected skipped refuse note %q", refuse.Note)
+	}
+	if recycling := skipped["Recycling"+NoCollectionSuffix]; recycling.Note != defaultSkippedNote {
+		t.Fatalf("expected default note on cancelled recycling, got %q", recycling.Note)
+	}
+}
+
+func TestParseCollectionsBrokenBlock(t *testing.T) {
+	html := loadFixture(t, "testdata/schedule_broken_block.html")
+
+	var logs bytes.Buffer
+	s, err := New(Config{
+		BaseURL:      "https://my.redbridge.gov.uk",
+		SchedulePath: "/RecycleRefuse",
+		UPRN:         "123",
+		StartHour:    6,
+		Timezone:     "Europe/London",
+		Logger:       slog.New(slog.NewTextHandler(&logs, nil)),
+	})
+	if err != nil {
+		t.Fatalf("New scraper: %v", err)
+	}
+
+	collections, err := s.collectionsFrom([]byte(html))
+	if err != nil {
+		t.Fatalf("collectionsFrom: %v", err)
+	}
+	counts := map[string]int{}
+	for _, c := range collections {
+		counts[c.Type]++
+	}
+	if counts["Refuse"] != 2 || counts["Food Waste"] != 1 || counts["Recycling"] != 0 {
+		t.Fatalf("expected the readable blocks only, got %v", counts)
+	}
+
+	out := logs.String()
+	if strings.Count(out, "schedule block failed to parse") != 1 {
+		t.Fatalf("expected one block failure logged, got %s", out)
+	}
+	for _, want := range []string{"type=Recycling", "2 unreadable entries", "TBC"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in log, got %s", want, out)
+		}
+	}
+}
+
+func TestParseCollectionsWeekdayMismatch(t *testing.T) {
+	html := loadFixture(t, "testdata/schedule_weekday_mismatch.html")
+
+	s, err := New(Config{
+		BaseURL:      "https://my.redbridge.gov.uk",
+		SchedulePath: "/RecycleRefuse",
+		UPRN:         "123",
+		StartHour:    6,
+		Timezone:     "Europe/London",
+	})
+	if err != nil {
+		t.Fatalf("New scraper: %v", err)
+	}
+
+	collections, err := s.parseCollections([]byte(html))
+	if err != nil {
+		t.Fatalf("parseCollections: %v", err)
+	}
+	if len(collections) != 3 {
+		t.Fatalf("expected 3 entries, got %d: %+v", len(collections), collections)
+	}
+
+	flagged := 0
+	for _, c := range collections {
+		if c.Note == "" {
+			continue
+		}
+		flagged++
+		// "Tuesday 09 November 2025" has the wrong month: the 9th is a Sunday.
+		if c.Date.Day() != 9 || c.Date.Month() != time.November {
+			t.Fatalf("unexpected flagged entry %+v", c)
+		}
+		if !strings.Contains(c.Note, "Tuesday") || !strings.Contains(c.Note, "Sunday") {
+			t.Fatalf("unexpected mismatch note %q", c.Note)
+		}
+	}
+	if flagged != 1 {
+		t.Fatalf("expected exactly one flagged entry, got %d: %+v", flagged, collections)
+	}
+}
+
+func TestParseCollectionsYearBoundary(t *testing.T) {
+	html := loadFixture(t, "testdata/schedule_year_boundary.html")
+
+	s, err := New(Config{
+		BaseURL:      "https://my.redbridge.gov.uk",
+		SchedulePath: "/RecycleRefuse",
+		UPRN:         "123",
+		StartHour:    6,
+		Timezone:     "Europe/London",
+	})
+	if err != nil {
+		t.Fatalf("New scraper: %v", err)
+	}
+	s.now = func() time.Time { return time.Date(2025, time.December, 28, 10, 0, 0, 0, time.UTC) }
+
+	collections, err := s.parseCollections([]byte(html))
+	if err != nil {
+		t.Fatalf("parseCollections: %v", err)
+	}
+	want := []string{"2025-12-30", "2026-01-06", "2026-01-13"}
+	if len(collections) != len(want) {
+		t.Fatalf("expected %d entries, got %+v", len(want), collections)
+	}
+	for i, c := range collections {
+		if got := c.Date.Format("2006-01-02"); got != want[i] {
+			t.Fatalf("collection %d: got %s, want %s", i, got, want[i])
+		}
+	}
+
+	// Scraped in early January, a just-passed December date is last year's.
+	s.now = func() time.Time { return time.Date(2026, time.January, 2, 10, 0, 0, 0, time.UTC) }
+	collections, err = s.parseCollections([]byte(html))
+	if err != nil {
+		t.Fatalf("parseCollections: %v", err)
+	}
+	if got := collections[0].Date.Format("2006-01-02"); got != "2025-12-30" {
+		t.Fatalf("expected December date to stay in 2025 when scraped in January, got %s", got)
+	}
+}
+
+func TestParseCollectionsTypeAliases(t *testing.T) {
+	html := loadFixture(t, "testdata/schedule_aliases.html")
+
+	s, err := New(Config{
+		BaseURL:      "https://my.redbridge.gov.uk",
+		SchedulePath: "/RecycleRefuse",
+		UPRN:         "123",
+		StartHour:    6,
+		Timezone:     "Europe/London",
+		TypeAliases:  map[string]string{"textiles": "Textile Recycling"},
+		StartHourByType: map[string]int{
+			"Refuse":            5,
+			"Textile Recycling": 8,
+		},
+	})
+	if err != nil {
+		t.Fatalf("New scraper: %v", err)
+	}
+
+	collections, err := s.parseCollections([]byte(html))
+	if err != nil {
+		t.Fatalf("parseCollections: %v", err)
+	}
+
+	counts := map[string]int{}
+	hours := map[string]int{"Refuse": 5, "Recycling": 6, "Textile Recycling": 8}
+	for _, c := range collections {
+		counts[c.Type]++
+		if c.Date.Hour() != hours[c.Type] {
+			t.Fatalf("expected %s at %02d:00 from its canonical type, got %s", c.Type, hours[c.Type], c.Date)
+		}
+	}
+	want := map[string]int{"Refuse": 1, "Recycling": 2, "Textile Recycling": 1}
+	if len(counts) != len(want) {
+		t.Fatalf("unexpected types: %v", counts)
+	}
+	for wasteType, n := range want {
+		if counts[wasteType] != n {
+			t.Fatalf("expected %d %s collections, got %v", n, wasteType, counts)
+		}
+	}
+}
+
+func TestParseCollectionsCustomParser(t *testing.T) {
+	custom := func(doc *goquery.Document) []Collection {
+		if doc.Find(".custom-layout").Length() == 0 {
+			return nil
+		}
+		return []Collection{{Type: "Custom"}}
+	}
+
+	s, err := New(Config{
+		BaseURL:      "https://my.redbridge.gov.uk",
+		SchedulePath: "/RecycleRefuse",
+		UPRN:         "123",
+		Timezone:     "Europe/London",
+		Parsers:      []Parser{custom},
+	})
+	if err != nil {
+		t.Fatalf("New scraper: %v", err)
+	}
+
+	collections, err := s.parseCollections([]byte(`<div class="custom-layout"></div>`))
+	if err != nil {
+		t.Fatalf("parseCollections: %v", err)
+	}
+	if len(collections) != 1 || collections[0].Type != "Custom" || collections[0].Source != "custom-1" {
+		t.Fatalf("expected custom parser result, got %+v", collections)
+	}
+
+	if _, err := s.parseCollections([]byte(`<div></div>`)); !errors.Is(err, ErrNoCollections) {
+		t.Fatalf("expected ErrNoCollections when no parser matches, got %v", err)
+	}
+}
+
+func TestFetchCollectionsSaveAddressFailureWithCookie(t *testing.T) {
+	html := loadFixture(t, "testdata/schedule.html")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/Shared/SaveAddress", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "RedbridgeIV3LivePref", Value: "abc"})
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/RecycleRefuse", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(html))
+	})
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	s, err := New(Config{
+		BaseURL:        ts.URL,
+		SchedulePath:   "/RecycleRefuse",
+		UPRN:           "123",
+		UserAgent:      "test-agent",
+		StartHour:      6,
+		RequestTimeout: time.Second,
+		Timezone:       "Europe/London",
+	})
+	if err != nil {
+		t.Fatalf("New scraper: %v", err)
+	}
+	s.client = ts.Client()
+
+	if _, err := s.FetchCollections(context.Background()); err != nil {
+		t.Fatalf("FetchCollections: %v", err)
+	}
+}
+
+func TestFetchCollectionsNoCollections(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/Shared/SaveAddress", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "RedbridgeIV3LivePref", Value: "abc"})
+	})
+	mux.HandleFunc("/RecycleRefuse", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	s, err := New(Config{
+		BaseURL:        ts.URL,
+		SchedulePath:   "/RecycleRefuse",
+		UPRN:           "123",
+		UserAgent:      "test-agent",
+		StartHour:      6,
+		RequestTimeout: time.Second,
+		Timezone:       "Europe/London",
+	})
+	if err != nil {
+		t.Fatalf("New scraper: %v", err)
+	}
+	s.client = ts.Client()
+
+	_, err = s.FetchCollections(context.Background())
+	if !errors.Is(err, ErrNoCollections) {
+		t.Fatalf("expected ErrNoCollections, got %v", err)
+	}
+}
+
+func TestRequestDelayJitter(t *testing.T) {
+	s, err := New(Config{
+		BaseURL:      "https://my.redbridge.gov.uk",
+		SchedulePath: "/RecycleRefuse",
+		UPRN:         "123",
+		Timezone:     "Europe/London",
+		RequestDelay: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if s.cfg.RequestDelay != time.Second {
+		t.Fatalf("expected RequestDelay to be honoured, got %s", s.cfg.RequestDelay)
+	}
+
+	if got := jitter(s.cfg.RequestDelay, 0); got != 500*time.Millisecond {
+		t.Fatalf("expected lower bound 500ms, got %s", got)
+	}
+	for i := 0; i < 100; i++ {
+		got := jitter(s.cfg.RequestDelay, s.random())
+		if got < 500*time.Millisecond || got >= 1500*time.Millisecond {
+			t.Fatalf("jittered delay %s outside [500ms, 1.5s)", got)
+		}
+	}
+
+	s, err = New(Config{
+		BaseURL:      "https://my.redbridge.gov.uk",
+		SchedulePath: "/RecycleRefuse",
+		UPRN:         "123",
+		Timezone:     "Europe/London",
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if s.cfg.RequestDelay != 150*time.Millisecond {
+		t.Fatalf("expected default RequestDelay 150ms, got %s", s.cfg.RequestDelay)
+	}
+}
+
+func TestLookupAddresses(t *testing.T) {
+	html := loadFixture(t, "testdata/address_search.html")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/Shared/AddressSearch", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("postcode") != "IG1 1AA" {
+			_, _ = w.Write([]byte(`<select><option value="">No addresses found</option></select>`))
+			return
+		}
+		_, _ = w.Write([]byte(html))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	s, err := New(Config{
+		BaseURL:        ts.URL,
+		SchedulePath:   "/RecycleRefuse",
+		UPRN:           "123",
+		RequestTimeout: time.Second,
+		Timezone:       "Europe/London",
+	})
+	if err != nil {
+		t.Fatalf("New scraper: %v", err)
+	}
+	s.client = ts.Client()
+
+	matches, err := s.LookupAddresses(context.Background(), " ig1 1aa ")
+	if err != nil {
+		t.Fatalf("LookupAddresses: %v", err)
+	}
+	if len(matches) != 3 {
+		t.Fatalf("expected 3 unique matches, got %+v", matches)
+	}
+	if matches[1].UPRN != "10023770001" || matches[1].AddressLine != "2 High Road, Ilford, IG1 1AA" {
+		t.Fatalf("unexpected match: %+v", matches[1])
+	}
+
+	if _, err := s.LookupAddresses(context.Background(), "ig1  1aa"); err != nil {
+		t.Fatalf("expected the double-spaced postcode to be normalized, got %v", err)
+	}
+	if _, err := s.LookupAddresses(context.Background(), "IG2 2BB"); !errors.Is(err, ErrNoAddresses) {
+		t.Fatalf("expected ErrNoAddresses, got %v", err)
+	}
+	if _, err := s.LookupAddresses(context.Background(), "gir0aa"); !errors.Is(err, ErrNoAddresses) {
+		t.Fatalf("expected GIR 0AA to pass validation, got %v", err)
+	}
+	if _, err := s.LookupAddresses(context.Background(), "not a postcode"); !errors.Is(err, ErrInvalidPostcode) {
+		t.Fatalf("expected ErrInvalidPostcode, got %v", err)
+	}
+}
+
+func TestResolveLink(t *testing.T) {
+	cases := map[string]string{
+		"/MissedCollection/garden":           "https://my.redbridge.gov.uk/MissedCollection/garden",
+		"MissedCollection/garden":            "https://my.redbridge.gov.uk/MissedCollection/garden",
+		"https://www.redbridge.gov.uk/bins":  "https://www.redbridge.gov.uk/bins",
+		"/Report?type=food&uprn=123#details": "https://my.redbridge.gov.uk/Report?type=food&uprn=123#details",
+	}
+	for href, want := range cases {
+		if got := resolveLink("https://my.redbridge.gov.uk", href); got != want {
+			t.Fatalf("resolveLink(%q) = %q, want %q", href, got, want)
+		}
+	}
+}
+
+func TestCollectionEqual(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+	base := Collection{Date: time.Date(2025, 12, 1, 6, 0, 0, 0, london), Type: "Refuse", Note: "Put bins out by 6am"}
+
+	sameInstantUTC := base
+	sameInstantUTC.Date = base.Date.UTC()
+	sameDayNewYork := base
+	sameDayNewYork.Date = time.Date(2025, 12, 1, 6, 0, 0, 0, newYork)
+	later := base
+	later.Date = base.Date.Add(30 * time.Minute)
+	for name, other := range map[string]Collection{"utc": sameInstantUTC, "new york": sameDayNewYork, "sub-hour": later} {
+		if !base.Equal(other) || base.Key() != other.Key() {
+			t.Fatalf("%s: expected %q to equal %q", name, base.Key(), other.Key())
+		}
+	}
+
+	otherNote := base
+	otherNote.Note = "Bank holiday: collected a day late"
+	otherType := base
+	otherType.Type = "Recycling"
+	otherDay := base
+	otherDay.Date = base.Date.AddDate(0, 0, 1)
+	for name, other := range map[string]Collection{"note": otherNote, "type": otherType, "date": otherDay} {
+		if base.Equal(other) {
+			t.Fatalf("%s: expected %q and %q to differ", name, base.Key(), other.Key())
+		}
+	}
+	if base.DayKey() != otherNote.DayKey() || base.DayKey() == otherType.DayKey() || base.DayKey() == otherDay.DayKey() {
+		t.Fatalf("expected DayKey to ignore only the note, got %q", base.DayKey())
+	}
+
+	now := time.Now()
+	withClock := Collection{Date: now, Type: "Refuse"}
+	if !withClock.Equal(Collection{Date: now.Round(0), Type: "Refuse"}) {
+		t.Fatalf("expected the monotonic clock reading to be ignored")
 	}
 }
 
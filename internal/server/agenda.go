@@ -0,0 +1,106 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// agendaPage renders the agenda as a minimal standalone HTML page.
+var agendaPage = template.Must(template.New("agenda").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem auto; max-width: 32rem; padding: 0 1rem; }
+ul { list-style: none; padding: 0; }
+li { padding: 0.5rem 0; border-bottom: 1px solid #ddd; }
+.date { display: inline-block; min-width: 7rem; font-weight: 600; }
+.marker { color: #fff; background: #2e7d32; border-radius: 0.25rem; padding: 0 0.4rem; margin-left: 0.5rem; font-size: 0.85em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Days}}<ul>
+{{range .Days}}<li><span class="date">{{.Date}}</span>{{.Types}}{{if .Marker}}<span class="marker">{{.Marker}}</span>{{end}}</li>
+{{end}}</ul>{{else}}<p>No upcoming collections.</p>{{end}}
+</body>
+</html>
+`))
+
+// agendaDay is one line of the agenda.
+type agendaDay struct {
+	Date   string
+	Types  string
+	Marker string
+}
+
+// agendaHandler lists upcoming collection days in a human-readable form,
+// as plain text by default or as HTML with ?format=html.
+func (s *Server) agendaHandler(w http.ResponseWriter, r *http.Request) {
+	now, ok := s.resolveNow(w, r)
+	if !ok {
+		return
+	}
+	addr, ok := s.resolveAddress(w, r)
+	if !ok {
+		return
+	}
+
+	collections, err := s.collectionsFor(r.Context(), w, addr)
+	if err != nil {
+		s.respondUnavailable(w, r, err)
+		return
+	}
+	collections = s.withinHorizon(collections, now)
+
+	var days []agendaDay
+	for _, day := range groupDays(collections) {
+		date := day.Date.In(s.location)
+		until := daysBetween(now, date, s.location)
+		if until < 0 {
+			continue
+		}
+		entry := agendaDay{
+			Date:  date.Format("Mon 02 Jan"),
+			Types: strings.Join(day.Types, ", "),
+		}
+		switch until {
+		case 0:
+			entry.Marker = "today"
+		case 1:
+			entry.Marker = "tomorrow"
+		}
+		days = append(days, entry)
+	}
+
+	setJSONCacheControl(w, r)
+	if r.URL.Query().Get("format") == "html" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		data := struct {
+			Title string
+			Days  []agendaDay
+		}{Title: s.cfg.CalendarName, Days: days}
+		if err := agendaPage.Execute(w, data); err != nil {
+			s.loggerFor(r.Context()).Warn("failed to write response", slog.String("error", err.Error()))
+		}
+		return
+	}
+
+	var out strings.Builder
+	for _, day := range days {
+		fmt.Fprintf(&out, "%s — %s", day.Date, day.Types)
+		if day.Marker != "" {
+			fmt.Fprintf(&out, " (%s)", day.Marker)
+		}
+		out.WriteString("\n")
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if _, err := w.Write([]byte(out.String())); err != nil {
+		s.loggerFor(r.Context()).Warn("failed to write response", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// requireToken rejects requests that do not carry cfg.AuthToken, either as a
+// Bearer token or a token query parameter for calendar clients that cannot
+// send headers. /healthz stays open for orchestrator probes, and /favicon.ico
+// for browsers, which never send the token. The middleware is a no-op when no
+// token is configured.
+func (s *Server) requireToken(next http.Handler) http.Handler {
+	if s.cfg.AuthToken == "" {
+		return next
+	}
+	want := []byte(s.cfg.AuthToken)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" || r.URL.Path == "/favicon.ico" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		got := r.URL.Query().Get("token")
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			got = strings.TrimSpace(bearer)
+		}
+		if subtle.ConstantTimeCompare([]byte(got), want) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="redbridge-ics"`)
+			writeProblem(w, r, http.StatusUnauthorized, "unauthorized", "A valid token is required.")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientIP returns the address of the client behind r. X-Real-IP and
+// X-Forwarded-For are only consulted with TRUST_PROXY set; the last
+// X-Forwarded-For hop is used, as that is the one the trusted proxy added
+// and the earlier hops can be forged by the client.
+func (s *Server) clientIP(r *http.Request) string {
+	if s.cfg.TrustProxy {
+		if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+			if addr, err := netip.ParseAddr(realIP); err == nil {
+				return addr.Unmap().String()
+			}
+		}
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			hops := strings.Split(forwarded[len(forwarded)-1], ",")
+			if addr, err := netip.ParseAddr(strings.TrimSpace(hops[len(hops)-1])); err == nil {
+				return addr.Unmap().String()
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ipAllowed reports whether the client is inside cfg.AllowedIPs. An empty
+// list allows everyone.
+func (s *Server) ipAllowed(r *http.Request) bool {
+	if len(s.cfg.AllowedIPs) == 0 {
+		return true
+	}
+	addr, err := netip.ParseAddr(s.clientIP(r))
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range s.cfg.AllowedIPs {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
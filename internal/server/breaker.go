@@ -0,0 +1,76 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// errCircuitOpen is returned in place of a scrape while the breaker is open.
+// It wraps the failure that opened the breaker, so callers can still
+// classify it.
+var errCircuitOpen = errors.New("circuit breaker open")
+
+// breaker stops scraping a failing origin. After threshold consecutive
+// failures it opens for cooldown, during which scrapes are refused. Once the
+// cooldown has passed a single probe is let through (half-open): success
+// closes the breaker, failure reopens it for another cooldown.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+	lastErr   error
+}
+
+// newBreaker returns a breaker; a threshold of zero or less disables it.
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a scrape may run at now, returning the error to
+// surface instead when it may not.
+func (b *breaker) allow(now time.Time) error {
+	if b.threshold <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return nil
+	}
+	if now.Before(b.openUntil) || b.probing {
+		return fmt.Errorf("%w: %w", errCircuitOpen, b.lastErr)
+	}
+	b.probing = true
+	return nil
+}
+
+// record notes the outcome of a scrape allowed at now, reporting whether
+// this failure opened the breaker.
+func (b *breaker) record(err error, now time.Time) (opened bool) {
+	if b.threshold <= 0 {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if err == nil {
+		b.failures = 0
+		b.lastErr = nil
+		return false
+	}
+	b.failures++
+	b.lastErr = err
+	if b.failures < b.threshold {
+		return false
+	}
+	b.openUntil = now.Add(b.cooldown)
+	return true
+}
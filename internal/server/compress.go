@@ -0,0 +1,142 @@
+package server
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// compressMinBytes is the smallest body worth compressing; tiny payloads such
+// as /healthz grow once gzip framing is added.
+const compressMinBytes = 1024
+
+// compress gzip- or deflate-encodes calendar and JSON responses when the
+// client accepts it. Other content types stream through untouched.
+func compress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(cw, r)
+		cw.finish(encoding)
+	})
+}
+
+// negotiateEncoding picks gzip or deflate from an Accept-Encoding header,
+// preferring gzip and honouring q=0 exclusions.
+func negotiateEncoding(header string) string {
+	accepted := map[string]bool{}
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		accepted[name] = qValue(fields[1:]) > 0
+	}
+
+	for _, encoding := range []string{"gzip", "deflate"} {
+		if accepted[encoding] {
+			return encoding
+		}
+	}
+	return ""
+}
+
+// qValue returns the q parameter among the params of one Accept or
+// Accept-Encoding element, defaulting to 1.
+func qValue(params []string) float64 {
+	q := 1.0
+	for _, param := range params {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+	}
+	return q
+}
+
+func compressible(contentType string) bool {
+	return strings.HasPrefix(contentType, "text/calendar") || strings.HasPrefix(contentType, "application/json")
+}
+
+// compressWriter buffers compressible 200 responses so the final size is known
+// before choosing whether to encode them. Anything else passes straight through.
+type compressWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	buffering   bool
+	buf         bytes.Buffer
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+	cw.status = status
+	cw.buffering = status == http.StatusOK && compressible(cw.Header().Get("Content-Type"))
+	if !cw.buffering {
+		cw.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.buffering {
+		return cw.buf.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+func (cw *compressWriter) Flush() {
+	if cw.buffering {
+		return
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (cw *compressWriter) finish(encoding string) {
+	if !cw.buffering {
+		return
+	}
+
+	header := cw.Header()
+	header.Add("Vary", "Accept-Encoding")
+	if cw.buf.Len() < compressMinBytes {
+		cw.ResponseWriter.WriteHeader(cw.status)
+		_, _ = cw.ResponseWriter.Write(cw.buf.Bytes())
+		return
+	}
+
+	var encoded bytes.Buffer
+	var enc io.WriteCloser
+	if encoding == "gzip" {
+		enc = gzip.NewWriter(&encoded)
+	} else {
+		enc, _ = flate.NewWriter(&encoded, flate.DefaultCompression)
+	}
+	_, _ = enc.Write(cw.buf.Bytes())
+	_ = enc.Close()
+
+	// The ETag identifies the uncompressed representation, so it is weakened
+	// for the encoded variant as RFC 9110 requires.
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		header.Set("ETag", "W/"+etag)
+	}
+	header.Del("Content-Length")
+	header.Set("Content-Encoding", encoding)
+	cw.ResponseWriter.WriteHeader(cw.status)
+	_, _ = cw.ResponseWriter.Write(encoded.Bytes())
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Takenobou/redbridge-council-rubbish-scraper/internal/scraper"
+)
+
+// Google Calendar's CSV import expects US-style dates and 12-hour times.
+const (
+	csvDateFormat = "01/02/2006"
+	csvTimeFormat = "03:04 PM"
+)
+
+var csvHeader = []string{"Subject", "Start Date", "Start Time", "End Date", "End Time", "Description"}
+
+// csvHandler serves the feed's events as a Google Calendar CSV import, for
+// users who want a one-off import rather than a subscription.
+func (s *Server) csvHandler(w http.ResponseWriter, r *http.Request) {
+	addr, ok := s.resolveAddress(w, r)
+	if !ok {
+		return
+	}
+	lister, ok := addr.calendar.(EventLister)
+	if !ok {
+		writeProblem(w, r, http.StatusNotImplemented, "events_unsupported", "The calendar builder does not support event listings.")
+		return
+	}
+
+	collections, err := s.collectionsFor(r.Context(), w, addr)
+	if err != nil {
+		s.respondScrapeError(w, r, err)
+		return
+	}
+	collections = filterTypes(collections, r.URL.Query().Get("types"))
+	collections = s.withinHorizon(collections, time.Now())
+
+	rows := [][]string{csvHeader}
+	// CSV has no recurrence, so build each day on its own to get one row per
+	// collection day even when the feed uses RRULEs.
+	for _, day := range s.collectionsByDay(collections) {
+		events, err := lister.Events(day)
+		if err != nil {
+			s.loggerFor(r.Context()).Error("calendar build failed", slog.String("error", err.Error()))
+			writeProblem(w, r, http.StatusInternalServerError, "calendar_failed", "The calendar could not be built.")
+			return
+		}
+		for _, e := range events {
+			start, end := e.Start.In(s.location), e.End.In(s.location)
+			row := []string{e.Summary, start.Format(csvDateFormat), "", "", "", e.Description}
+			if e.AllDay {
+				// ICS all-day ends are exclusive; Google's are inclusive.
+				row[3] = end.AddDate(0, 0, -1).Format(csvDateFormat)
+			} else {
+				row[2] = start.Format(csvTimeFormat)
+				row[3] = end.Format(csvDateFormat)
+				row[4] = end.Format(csvTimeFormat)
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", s.calendarFilename(".csv")))
+	w.Header().Set("Cache-Control", cacheControlICS)
+	w.WriteHeader(http.StatusOK)
+	if err := csv.NewWriter(w).WriteAll(rows); err != nil {
+		s.loggerFor(r.Context()).Warn("failed to write response", slog.String("error", err.Error()))
+	}
+}
+
+// collectionsByDay buckets collections by local calendar day, in date order.
+func (s *Server) collectionsByDay(collections []scraper.Collection) [][]scraper.Collection {
+	index := map[string][]scraper.Collection{}
+	var keys []string
+	for _, c := range collections {
+		key := c.Date.In(s.location).Format("2006-01-02")
+		if _, ok := index[key]; !ok {
+			keys = append(keys, key)
+		}
+		index[key] = append(index[key], c)
+	}
+	sort.Strings(keys)
+
+	days := make([][]scraper.Collection, 0, len(keys))
+	for _, key := range keys {
+		days = append(days, index[key])
+	}
+	return days
+}
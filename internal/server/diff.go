@@ -0,0 +1,159 @@
+package server
+
+import (
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/Takenobou/redbridge-council-rubbish-scraper/internal/scraper"
+)
+
+// Kinds of schedule change reported by diffCollections.
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeMoved   = "moved"
+)
+
+// maxMoveDays is how far a collection can shift and still be reported as a
+// move rather than a removal plus an addition. Bank holiday reschedules are
+// a day or two; anything a week or more away is a different collection.
+const maxMoveDays = 6
+
+// Change is one difference between two scrapes of the same address. From is
+// zero for additions and To is zero for removals.
+type Change struct {
+	Kind string
+	Type string
+	From time.Time
+	To   time.Time
+}
+
+// diffCollections compares two scrapes by type and calendar day. A removed
+// day paired with an added day of the same type at most maxMoveDays away is
+// reported as a move. Changes are ordered by date, then type.
+func diffCollections(previous, current []scraper.Collection) []Change {
+	before := datesByType(previous)
+	after := datesByType(current)
+
+	var types []string
+	for wasteType := range before {
+		types = append(types, wasteType)
+	}
+	for wasteType := range after {
+		if _, ok := before[wasteType]; !ok {
+			types = append(types, wasteType)
+		}
+	}
+	sort.Strings(types)
+
+	var changes []Change
+	for _, wasteType := range types {
+		removed := missingDates(before[wasteType], after[wasteType])
+		added := missingDates(after[wasteType], before[wasteType])
+
+		for _, from := range removed {
+			best := -1
+			for i, to := range added {
+				if to.IsZero() || absDays(from, to) > maxMoveDays {
+					continue
+				}
+				if best < 0 || absDays(from, to) < absDays(from, added[best]) {
+					best = i
+				}
+			}
+			if best < 0 {
+				changes = append(changes, Change{Kind: ChangeRemoved, Type: wasteType, From: from})
+				continue
+			}
+			changes = append(changes, Change{Kind: ChangeMoved, Type: wasteType, From: from, To: added[best]})
+			added[best] = time.Time{}
+		}
+		for _, to := range added {
+			if !to.IsZero() {
+				changes = append(changes, Change{Kind: ChangeAdded, Type: wasteType, To: to})
+			}
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].date().Before(changes[j].date())
+	})
+	return changes
+}
+
+// logScheduleChanges reports how a fresh scrape differs from the previous one.
+// Days before today are ignored on both sides, so collections rolling off the
+// schedule aren't reported as removals.
+func (s *Server) logScheduleChanges(logger *slog.Logger, addr *address, previous, current []scraper.Collection) {
+	now := time.Now().In(s.location)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.location)
+	for _, change := range diffCollections(upcomingFrom(previous, midnight), upcomingFrom(current, midnight)) {
+		attrs := []any{
+			slog.String("uprn", addr.uprn),
+			slog.String("change", change.Kind),
+			slog.String("type", change.Type),
+		}
+		if !change.From.IsZero() {
+			attrs = append(attrs, slog.String("from", change.From.In(s.location).Format("2006-01-02")))
+		}
+		if !change.To.IsZero() {
+			attrs = append(attrs, slog.String("to", change.To.In(s.location).Format("2006-01-02")))
+		}
+		logger.Info("schedule changed", attrs...)
+		if s.metrics != nil {
+			s.metrics.scheduleChanges.WithLabelValues(change.Kind).Inc()
+		}
+	}
+}
+
+// upcomingFrom keeps the collections on or after from.
+func upcomingFrom(collections []scraper.Collection, from time.Time) []scraper.Collection {
+	var kept []scraper.Collection
+	for _, c := range collections {
+		if !c.Date.Before(from) {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// date is the earliest date the change concerns, for ordering.
+func (c Change) date() time.Time {
+	if c.From.IsZero() || (!c.To.IsZero() && c.To.Before(c.From)) {
+		return c.To
+	}
+	return c.From
+}
+
+// datesByType maps each type to its collection dates, one per calendar day.
+func datesByType(collections []scraper.Collection) map[string]map[string]time.Time {
+	dates := map[string]map[string]time.Time{}
+	for _, c := range collections {
+		if dates[c.Type] == nil {
+			dates[c.Type] = map[string]time.Time{}
+		}
+		dates[c.Type][c.DayKey()] = c.Date
+	}
+	return dates
+}
+
+// missingDates returns the dates in a whose day is absent from b, sorted.
+func missingDates(a, b map[string]time.Time) []time.Time {
+	var missing []time.Time
+	for day, date := range a {
+		if _, ok := b[day]; !ok {
+			missing = append(missing, date)
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool { return missing[i].Before(missing[j]) })
+	return missing
+}
+
+func absDays(a, b time.Time) int {
+	days := int(a.Sub(b).Hours() / 24)
+	if days < 0 {
+		return -days
+	}
+	return days
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Takenobou/redbridge-council-rubbish-scraper/internal/scraper"
+	"golang.org/x/sync/errgroup"
+)
+
+const defaultScrapeConcurrency = 2
+
+var errUnknownUPRN = errors.New("unknown UPRN")
+
+// fetchResult is the outcome of fetching a single address in fetchAll.
+type fetchResult struct {
+	Collections []scraper.Collection
+	Err         error
+}
+
+// fetchAll fetches collections for uprns concurrently, running at most
+// cfg.ScrapeConcurrency scrapes at once. Each address succeeds or fails on its
+// own; cancelling ctx abandons any scrapes still waiting or in flight.
+func (s *Server) fetchAll(ctx context.Context, uprns []string) map[string]fetchResult {
+	limit := s.cfg.ScrapeConcurrency
+	if limit <= 0 {
+		limit = defaultScrapeConcurrency
+	}
+
+	var (
+		mu      sync.Mutex
+		g       errgroup.Group
+		results = make(map[string]fetchResult, len(uprns))
+	)
+	record := func(uprn string, res fetchResult) {
+		mu.Lock()
+		results[uprn] = res
+		mu.Unlock()
+	}
+
+	// Errors are kept per UPRN rather than returned to the group, so one
+	// failing address neither cancels nor hides the others.
+	g.SetLimit(limit)
+	for _, uprn := range uprns {
+		addr, ok := s.addresses[uprn]
+		if !ok {
+			record(uprn, fetchResult{Err: errUnknownUPRN})
+			continue
+		}
+
+		g.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				record(uprn, fetchResult{Err: err})
+				return nil
+			}
+			items, err := s.collections(ctx, addr, false)
+			record(uprn, fetchResult{Collections: items, Err: err})
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	return results
+}
+
+// prefetch warms the cache of every registered address so the first request
+// for each does not pay for a cold scrape.
+func (s *Server) prefetch(ctx context.Context) {
+	uprns := make([]string, 0, len(s.addresses))
+	for uprn := range s.addresses {
+		uprns = append(uprns, uprn)
+	}
+	sort.Strings(uprns)
+
+	for uprn, res := range s.fetchAll(ctx, uprns) {
+		if res.Err != nil {
+			s.logger.Warn("prefetch failed", slog.String("uprn", uprn), slog.String("error", res.Err.Error()))
+		}
+	}
+}
+
+// refreshLoop re-scrapes every address each cfg.RefreshInterval so requests
+// are served from a warm cache, until ctx is cancelled. Ticks outside
+// SCRAPE_DAYS are skipped, as are addresses already being refreshed.
+func (s *Server) refreshLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if !s.shouldScrape(now) {
+				continue
+			}
+			s.refreshAll(ctx)
+		}
+	}
+}
+
+func (s *Server) refreshAll(ctx context.Context) {
+	uprns := make([]string, 0, len(s.addresses))
+	for uprn := range s.addresses {
+		uprns = append(uprns, uprn)
+	}
+	sort.Strings(uprns)
+
+	for _, uprn := range uprns {
+		if ctx.Err() != nil {
+			return
+		}
+		addr := s.addresses[uprn]
+		if !addr.refreshing.CompareAndSwap(false, true) {
+			continue
+		}
+		items, err := s.collections(ctx, addr, true)
+		addr.refreshing.Store(false)
+		if err != nil {
+			s.logger.Warn("scheduled refresh failed", slog.String("uprn", uprn), slog.String("error", err.Error()))
+			continue
+		}
+		s.logger.Info("scheduled refresh complete", slog.String("uprn", uprn), slog.Int("items", len(items)))
+	}
+}
+
+// shouldScrape reports whether proactive refreshes may run at now, limited to
+// cfg.ScrapeDays in the server's timezone when set. Requests that find the
+// cache empty or expired still scrape on any day.
+func (s *Server) shouldScrape(now time.Time) bool {
+	if len(s.cfg.ScrapeDays) == 0 {
+		return true
+	}
+	weekday := now.In(s.location).Weekday()
+	for _, day := range s.cfg.ScrapeDays {
+		if day == weekday {
+			return true
+		}
+	}
+	return false
+}
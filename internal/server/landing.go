@@ -0,0 +1,117 @@
+package server
+
+import (
+	_ "embed"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// faviconICO is a 16x16 wheelie bin, served so browsers opening the service
+// don't log a 404 for it.
+//
+//go:embed favicon.ico
+var faviconICO []byte
+
+// landingPage renders the service root as a short pointer to the feeds.
+var landingPage = template.Must(template.New("landing").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Name}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem auto; max-width: 36rem; padding: 0 1rem; line-height: 1.5; }
+code { background: #f3f3f3; border-radius: 0.25rem; padding: 0 0.25rem; word-break: break-all; }
+</style>
+</head>
+<body>
+<h1>{{.Name}}</h1>
+{{if .Description}}<p>{{.Description}}</p>
+{{end}}<h2>Subscribe</h2>
+<p>Add this URL to your calendar app: <code>{{.ICS}}</code></p>
+<p><a href="{{.Webcal}}">Open in your calendar app</a> &middot; <a href="{{.Download}}">Download the .ics file</a></p>
+<h2>JSON endpoints</h2>
+<ul>
+{{range .Endpoints}}<li><a href="{{.URL}}"><code>{{.Path}}</code></a></li>
+{{end}}</ul>
+<p>See <a href="/openapi.json"><code>/openapi.json</code></a> for the full API description.</p>
+</body>
+</html>
+`))
+
+// landingEndpoints are the read-only JSON endpoints linked from the landing
+// page.
+var landingEndpoints = []string{
+	"/api/next",
+	"/api/week",
+	"/api/schedule",
+	"/api/types",
+	"/api/is-today",
+	"/api/is-tomorrow",
+	"/api/soon",
+	"/api/events",
+	"/api/feeds",
+	"/healthz",
+}
+
+// landingHandler serves a static page at / so opening the service in a
+// browser shows how to use it instead of a 404. It never scrapes.
+func (s *Server) landingHandler(w http.ResponseWriter, r *http.Request) {
+	// Subscription URLs cannot send headers, so carry a query token through.
+	query := url.Values{}
+	if token := r.URL.Query().Get("token"); token != "" {
+		query.Set("token", token)
+	}
+	feedURL := func(scheme string, q url.Values) string {
+		u := url.URL{Scheme: scheme, Host: requestHost(r), Path: "/calendar.ics", RawQuery: q.Encode()}
+		return u.String()
+	}
+	download := url.Values{"download": {"1"}}
+	for k, v := range query {
+		download[k] = v
+	}
+	type endpoint struct{ Path, URL string }
+	endpoints := make([]endpoint, 0, len(landingEndpoints))
+	for _, path := range landingEndpoints {
+		u := url.URL{Path: path, RawQuery: query.Encode()}
+		endpoints = append(endpoints, endpoint{Path: path, URL: u.String()})
+	}
+	// html/template only trusts http(s) and mailto links, so vouch for webcal.
+	webcal := template.URL(feedURL("webcal", query))
+
+	data := struct {
+		Name        string
+		Description string
+		ICS         string
+		Webcal      template.URL
+		Download    string
+		Endpoints   []endpoint
+	}{
+		Name:        s.cfg.CalendarName,
+		Description: s.cfg.CalendarDesc,
+		ICS:         feedURL(requestScheme(r), query),
+		Webcal:      webcal,
+		Download:    feedURL(requestScheme(r), download),
+		Endpoints:   endpoints,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", cacheControlJSON)
+	if err := landingPage.Execute(w, data); err != nil {
+		s.loggerFor(r.Context()).Warn("failed to write response", slog.String("error", err.Error()))
+	}
+}
+
+// faviconHandler serves the embedded icon.
+func (s *Server) faviconHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "image/x-icon")
+	w.Header().Set("Content-Length", strconv.Itoa(len(faviconICO)))
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(faviconICO); err != nil {
+		s.loggerFor(r.Context()).Warn("failed to write response", slog.String("error", err.Error()))
+	}
+}
@@ -2,19 +2,29 @@ package server
 
 import (
 	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/Takenobou/redbridge-council-rubbish-scraper/internal/scraper"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type metrics struct {
-	registry       *prometheus.Registry
-	cacheHits      prometheus.Counter
-	cacheMisses    prometheus.Counter
-	scrapeRequests prometheus.Counter
-	scrapeFailures prometheus.Counter
-	scrapeDuration prometheus.Histogram
-	lastScrapeTime prometheus.Gauge
+	registry        *prometheus.Registry
+	cacheHits       prometheus.Counter
+	cacheMisses     prometheus.Counter
+	scrapeRequests  prometheus.Counter
+	scrapeFailures  prometheus.Counter
+	scrapeDuration  prometheus.Histogram
+	lastScrapeTime  prometheus.Gauge
+	parserUsed      *prometheus.CounterVec
+	scheduleChanges *prometheus.CounterVec
+
+	collectionsByType *prometheus.GaugeVec
+	typesMu           sync.Mutex
+	knownTypes        map[string]map[string]struct{}
 }
 
 func newMetrics() *metrics {
@@ -47,6 +57,19 @@ func newMetrics() *metrics {
 			Name: "redbridge_last_scrape_timestamp_seconds",
 			Help: "Unix timestamp of the last successful scrape",
 		}),
+		parserUsed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "redbridge_parser_used_total",
+			Help: "Number of successful scrapes parsed by each page layout parser",
+		}, []string{"parser"}),
+		scheduleChanges: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "redbridge_schedule_changes_total",
+			Help: "Number of collections added, removed or moved between successive scrapes",
+		}, []string{"change"}),
+		collectionsByType: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "redbridge_collections",
+			Help: "Number of collections of each type found by the last successful scrape of each address",
+		}, []string{"type", "uprn"}),
+		knownTypes: make(map[string]map[string]struct{}),
 	}
 
 	reg.MustRegister(
@@ -56,11 +79,59 @@ func newMetrics() *metrics {
 		m.scrapeFailures,
 		m.scrapeDuration,
 		m.lastScrapeTime,
+		m.parserUsed,
+		m.scheduleChanges,
+		m.collectionsByType,
 	)
 
 	return m
 }
 
+// observeCollections records how many collections of each type a scrape of
+// uprn returned. Types seen on earlier scrapes of the same address but now
+// missing are set to zero rather than dropped, so alerts on a vanished waste
+// stream still fire.
+func (m *metrics) observeCollections(uprn string, collections []scraper.Collection) {
+	counts := make(map[string]int)
+	for _, c := range collections {
+		counts[c.Type]++
+	}
+
+	m.typesMu.Lock()
+	defer m.typesMu.Unlock()
+	known := m.knownTypes[uprn]
+	if known == nil {
+		known = make(map[string]struct{})
+		m.knownTypes[uprn] = known
+	}
+	for wasteType := range known {
+		if _, ok := counts[wasteType]; !ok {
+			m.collectionsByType.WithLabelValues(wasteType, uprn).Set(0)
+		}
+	}
+	for wasteType, n := range counts {
+		known[wasteType] = struct{}{}
+		m.collectionsByType.WithLabelValues(wasteType, uprn).Set(float64(n))
+	}
+}
+
+// parsersUsed returns the distinct Collection.Source values, sorted.
+func parsersUsed(collections []scraper.Collection) []string {
+	seen := make(map[string]struct{})
+	var sources []string
+	for _, c := range collections {
+		if c.Source == "" {
+			continue
+		}
+		if _, ok := seen[c.Source]; !ok {
+			seen[c.Source] = struct{}{}
+			sources = append(sources, c.Source)
+		}
+	}
+	sort.Strings(sources)
+	return sources
+}
+
 func (m *metrics) handler() http.Handler {
 	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
 }
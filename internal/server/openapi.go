@@ -0,0 +1,25 @@
+package server
+
+import (
+	_ "embed"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// openAPIDocument describes every route for client generators. It is written
+// by hand, so new endpoints and response fields must be added to it too.
+//
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// openAPIHandler serves the static OpenAPI description; it never scrapes.
+func (s *Server) openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(len(openAPIDocument)))
+	w.Header().Set("Cache-Control", cacheControlJSON)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(openAPIDocument); err != nil {
+		s.loggerFor(r.Context()).Warn("failed to write response", slog.String("error", err.Error()))
+	}
+}
@@ -2,23 +2,37 @@ package server
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/Takenobou/redbridge-council-rubbish-scraper/internal/calendar"
 	"github.com/Takenobou/redbridge-council-rubbish-scraper/internal/config"
 	"github.com/Takenobou/redbridge-council-rubbish-scraper/internal/scraper"
+	"golang.org/x/sync/singleflight"
 )
 
 const (
+	// collectionDuration is the default TODAY_WINDOW.
 	collectionDuration = time.Hour
 	cacheControlICS    = "public, max-age=300"
+	cacheControlJSON   = "public, max-age=60"
+	refreshInterval    = time.Minute
+	// defaultScrapeTimeout bounds a shared scrape when SCRAPE_TIMEOUT is
+	// unset.
+	defaultScrapeTimeout = 2 * time.Minute
 )
 
 // Scraper abstracts collection lookups for easier testing.
@@ -26,59 +40,209 @@ type Scraper interface {
 	FetchCollections(context.Context) ([]scraper.Collection, error)
 }
 
+// AddressLookup is implemented by scrapers that can search addresses by
+// postcode.
+type AddressLookup interface {
+	LookupAddresses(ctx context.Context, postcode string) ([]scraper.AddressMatch, error)
+}
+
+// HTMLFetcher is implemented by scrapers that can return the raw schedule
+// page, used by the debug endpoint.
+type HTMLFetcher interface {
+	FetchHTML(ctx context.Context) ([]byte, error)
+}
+
+// AddressValidator is implemented by scrapers that can check the configured
+// address without scraping the schedule, used by /api/validate.
+type AddressValidator interface {
+	ValidateAddress(ctx context.Context) error
+}
+
+// GardenReporter is implemented by scrapers that can tell whether the paid
+// garden-waste service is active, suspended or not subscribed, reported by
+// /api/schedule.
+type GardenReporter interface {
+	GardenStatus() scraper.GardenStatus
+}
+
 // CalendarBuilder abstracts ICS generation.
 type CalendarBuilder interface {
 	Build([]scraper.Collection) ([]byte, error)
 }
 
-// Server wires together HTTP endpoints, the scraper, and the calendar builder.
+// FreeBusyBuilder is implemented by calendar builders that can render
+// collections as a VFREEBUSY, used by /freebusy.ifb.
+type FreeBusyBuilder interface {
+	FreeBusy(collections []scraper.Collection, from, to time.Time) ([]byte, error)
+}
+
+// EventLister is implemented by calendar builders that can expose their
+// events as data, used by /api/events.
+type EventLister interface {
+	Events([]scraper.Collection) ([]calendar.Event, error)
+}
+
+// UPRNScoper is implemented by calendar builders that can tag event UIDs with
+// the property they describe, used with UID_INCLUDE_UPRN.
+type UPRNScoper interface {
+	WithUPRN(uprn string) *calendar.Builder
+}
+
+// LocationScoper is implemented by calendar builders whose event LOCATION can
+// be replaced, so feeds for further addresses don't show the primary one.
+type LocationScoper interface {
+	WithLocation(location string) *calendar.Builder
+}
+
+// Server wires together HTTP endpoints, the scrapers, and the calendar builder.
 type Server struct {
 	cfg        config.Config
-	scraper    Scraper
 	calendar   CalendarBuilder
 	logger     *slog.Logger
 	httpServer *http.Server
-	cache      *collectionCache
+	addresses  map[string]*address
+	primary    *address
 	location   *time.Location
 	metrics    *metrics
+	webhook    *failureWebhook
+	flights    singleflight.Group
+
+	refreshMu   sync.Mutex
+	lastRefresh time.Time
+
+	healthMu    sync.RWMutex
+	startedAt   time.Time
+	lastSuccess time.Time
+	lastError   string
 }
 
-// New prepares a Server for use.
-func New(cfg config.Config, scr Scraper, cal CalendarBuilder, logger *slog.Logger) *Server {
+// address pairs the scraper, cache and calendar builder serving a single
+// UPRN. Each has its own breaker, so one misconfigured address cannot stop
+// the others being scraped.
+type address struct {
+	uprn       string
+	scraper    Scraper
+	calendar   CalendarBuilder
+	cache      *collectionCache
+	breaker    *breaker
+	refreshing atomic.Bool
+}
+
+// New prepares a Server for use. scr serves the primary address (cfg.UPRN);
+// further addresses can be registered with AddAddress.
+func New(cfg config.Config, scr Scraper, cal CalendarBuilder, logger *slog.Logger) (*Server, error) {
 	if logger == nil {
 		logger = slog.Default()
 	}
 
-	loc, _ := time.LoadLocation(cfg.Timezone)
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("load timezone %q: %w", cfg.Timezone, err)
+	}
 
 	m := newMetrics()
 
+	primary := &address{
+		uprn:    cfg.UPRN,
+		scraper: scr,
+		cache:   newCollectionCache(cfg.CacheFile, cfg.CacheTTL),
+		breaker: newBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+	}
+
 	s := &Server{
-		cfg:      cfg,
-		scraper:  scr,
-		calendar: cal,
-		logger:   logger,
-		cache:    newCollectionCache(),
-		location: loc,
-		metrics:  m,
+		cfg:       cfg,
+		calendar:  cal,
+		logger:    logger,
+		addresses: map[string]*address{cfg.UPRN: primary},
+		primary:   primary,
+		location:  loc,
+		metrics:   m,
+		webhook:   newFailureWebhook(cfg.FailureWebhook, cfg.WebhookThreshold, cfg.WebhookTimeout, logger),
+		startedAt: time.Now(),
 	}
+	primary.calendar = s.calendarFor(cfg.UPRN)
 
+	// GET patterns also match HEAD; net/http discards the body, so HEAD gets
+	// the same ETag and caching headers for cheap update polling.
 	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.landingHandler)
+	mux.HandleFunc("GET /favicon.ico", s.faviconHandler)
 	mux.HandleFunc("GET /healthz", s.healthHandler)
 	mux.HandleFunc("GET /calendar.ics", s.calendarHandler)
+	mux.HandleFunc("GET /calendar/{file}", s.typeCalendarHandler)
+	mux.HandleFunc("GET /calendar.webcal", s.webcalHandler)
+	mux.HandleFunc("GET /calendar.csv", s.csvHandler)
+	mux.HandleFunc("GET /freebusy.ifb", s.freeBusyHandler)
 	mux.HandleFunc("GET /api/next", s.nextHandler)
+	mux.HandleFunc("GET /api/next/{type}", s.nextTypeHandler)
+	mux.HandleFunc("GET /api/countdown", s.countdownHandler)
+	mux.HandleFunc("GET /api/week", s.weekHandler)
+	mux.HandleFunc("GET /api/schedule", s.scheduleHandler)
+	mux.HandleFunc("GET /api/agenda", s.agendaHandler)
 	mux.HandleFunc("GET /api/types", s.typesHandler)
 	mux.HandleFunc("GET /api/is-today", s.isTodayHandler)
 	mux.HandleFunc("GET /api/is-tomorrow", s.isTomorrowHandler)
+	mux.HandleFunc("GET /api/soon", s.soonHandler)
+	mux.HandleFunc("GET /api/feeds", s.feedsHandler)
+	mux.HandleFunc("GET /api/stream", s.streamHandler)
+	mux.HandleFunc("GET /api/events", s.eventsHandler)
+	mux.HandleFunc("GET /api/addresses", s.addressesHandler)
+	mux.HandleFunc("GET /api/validate", s.validateHandler)
+	mux.HandleFunc("POST /api/refresh", s.refreshHandler)
 	mux.Handle("GET /metrics", s.metrics.handler())
+	mux.HandleFunc("GET /openapi.json", s.openAPIHandler)
+	if cfg.Debug {
+		// The raw page includes the address, so it is only served on request.
+		mux.HandleFunc("GET /debug/html", s.debugHTMLHandler)
+	}
 
 	s.httpServer = &http.Server{
 		Addr:              cfg.ListenAddr,
-		Handler:           mux,
+		Handler:           s.logRequests(s.cors(s.requireToken(compress(mux)))),
 		ReadHeaderTimeout: 5 * time.Second,
 	}
 
-	return s
+	return s, nil
+}
+
+// AddAddress registers a scraper for an additional UPRN, selectable on each
+// endpoint via the uprn query parameter.
+func (s *Server) AddAddress(uprn string, scr Scraper) {
+	if _, exists := s.addresses[uprn]; exists {
+		return
+	}
+	s.addresses[uprn] = &address{
+		uprn:     uprn,
+		scraper:  scr,
+		calendar: s.calendarFor(uprn),
+		cache:    newCollectionCache(cacheFileFor(s.cfg.CacheFile, uprn), s.cfg.CacheTTL),
+		breaker:  newBreaker(s.cfg.BreakerThreshold, s.cfg.BreakerCooldown),
+	}
+}
+
+// calendarFor returns the builder for uprn's feeds: the shared one, scoped to
+// the UPRN when UID_INCLUDE_UPRN is set and the builder supports it. The
+// shared LOCATION is the primary property's address line, so it is dropped
+// for every other address.
+func (s *Server) calendarFor(uprn string) CalendarBuilder {
+	cal := s.calendar
+	if scoper, ok := cal.(UPRNScoper); ok && s.cfg.UIDIncludeUPRN {
+		cal = scoper.WithUPRN(uprn)
+	}
+	if scoper, ok := cal.(LocationScoper); ok && uprn != s.cfg.UPRN {
+		cal = scoper.WithLocation("")
+	}
+	return cal
+}
+
+// cacheFileFor derives a per-UPRN cache path from the primary CACHE_FILE so
+// additional addresses do not overwrite each other.
+func cacheFileFor(path, uprn string) string {
+	if path == "" {
+		return ""
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + uprn + ext
 }
 
 // Run starts the HTTP server and blocks until shutdown.
@@ -92,131 +256,1047 @@ func (s *Server) Run(ctx context.Context) error {
 		}
 	}()
 
+	// With several addresses a cold start would otherwise scrape them one
+	// request at a time; warm them up front within the concurrency limit.
+	if len(s.addresses) > 1 {
+		go s.prefetch(ctx)
+	}
+	if s.cfg.RefreshInterval > 0 {
+		go s.refreshLoop(ctx)
+	}
+
 	s.logger.Info("listening", slog.String("addr", s.cfg.ListenAddr))
 	return s.httpServer.ListenAndServe()
 }
 
-func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
-	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
-}
+func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
+	s.healthMu.RLock()
+	lastSuccess := s.lastSuccess
+	lastError := s.lastError
+	startedAt := s.startedAt
+	s.healthMu.RUnlock()
+
+	resp := map[string]interface{}{
+		"status":                 "ok",
+		"last_successful_scrape": nil,
+		"cache_age_seconds":      nil,
+		"last_error":             nil,
+	}
+	if !lastSuccess.IsZero() {
+		resp["last_successful_scrape"] = lastSuccess.UTC().Format(time.RFC3339)
+	}
+	if fetched := s.primary.cache.FetchedAt(); !fetched.IsZero() {
+		resp["cache_age_seconds"] = int(time.Since(fetched).Seconds())
+	}
+	if lastError != "" {
+		resp["last_error"] = lastError
+	}
+
+	// Degrade once nothing has been scraped for twice CACHE_TTL, but only
+	// when scrapes are expected: a refresh loop is running or the latest
+	// attempt failed. Without either, scrapes are on demand and an idle
+	// instance is healthy.
+	reference := lastSuccess
+	if reference.IsZero() {
+		reference = startedAt
+	}
+	expected := s.cfg.RefreshInterval > 0 || lastError != ""
+	if expected && s.cfg.CacheTTL > 0 && time.Since(reference) > 2*s.cfg.CacheTTL {
+		resp["status"] = "degraded"
+		writeJSON(w, http.StatusServiceUnavailable, resp)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) calendarHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	addr, ok := s.resolveAddress(w, r)
+	if !ok {
+		return
+	}
+	// Clients asking for JSON get the /api/events representation of the same
+	// feed; calendar apps send */* or nothing and keep getting ICS.
+	w.Header().Add("Vary", "Accept")
+	lister, asJSON := addr.calendar.(EventLister)
+	asJSON = asJSON && prefersJSON(r.Header.Get("Accept"))
+	cacheControl := cacheControlICS
+	if asJSON {
+		cacheControl = cacheControlJSON
+	}
+	if s.notModifiedSinceFetch(w, r, addr, cacheControl) {
+		return
+	}
+
+	collections, err := s.collectionsFor(ctx, w, addr)
+	if err != nil {
+		s.respondScrapeError(w, r, err)
+		return
+	}
+	collections = filterTypes(collections, r.URL.Query().Get("types"))
+	if asJSON {
+		s.writeEvents(w, r, lister, collections)
+		return
+	}
+	s.writeCalendar(w, r, addr, collections)
+}
+
+// prefersJSON reports whether an Accept header ranks application/json above
+// text/calendar. Each type takes the q of its most specific matching range,
+// and ties, */* and an absent header all go to the calendar.
+func prefersJSON(accept string) bool {
+	if strings.TrimSpace(accept) == "" {
+		return false
+	}
+	quality := func(mediaType string) float64 {
+		major, _, _ := strings.Cut(mediaType, "/")
+		best, specificity := 0.0, -1
+		for _, part := range strings.Split(accept, ",") {
+			fields := strings.Split(part, ";")
+			name := strings.ToLower(strings.TrimSpace(fields[0]))
+			rank := -1
+			switch name {
+			case mediaType:
+				rank = 2
+			case major + "/*":
+				rank = 1
+			case "*/*":
+				rank = 0
+			}
+			if rank < 0 || rank < specificity {
+				continue
+			}
+			best, specificity = qValue(fields[1:]), rank
+		}
+		return best
+	}
+	return quality("application/json") > quality("text/calendar")
+}
+
+// calendarFilename is the download name for the feed with extension ext,
+// derived from the calendar name, e.g. "redbridge-collections.ics".
+func (s *Server) calendarFilename(ext string) string {
+	name := calendar.Slug(s.cfg.CalendarName)
+	if name == "" {
+		name = "calendar"
+	}
+	return name + ext
+}
+
+// typeCalendarHandler serves a single-type feed at /calendar/{slug}.ics, for
+// clients that would rather subscribe to a clean URL per stream than pass
+// ?types=.
+func (s *Server) typeCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	name, ok := strings.CutSuffix(r.PathValue("file"), ".ics")
+	if !ok || name == "" {
+		http.NotFound(w, r)
+		return
+	}
+	addr, ok := s.resolveAddress(w, r)
+	if !ok {
+		return
+	}
+
+	collections, err := s.collectionsFor(r.Context(), w, addr)
+	if err != nil {
+		s.respondScrapeError(w, r, err)
+		return
+	}
+	matched := filterSlug(collections, name)
+	if len(matched) == 0 {
+		writeProblem(w, r, http.StatusNotFound, "unknown_type", fmt.Sprintf("No collection type matches %q.", name))
+		return
+	}
+	s.writeCalendar(w, r, addr, matched)
+}
+
+// writeCalendar renders collections as an ICS feed, honouring HORIZON and the
+// conditional request headers.
+func (s *Server) writeCalendar(w http.ResponseWriter, r *http.Request, addr *address, collections []scraper.Collection) {
+	ctx := r.Context()
+	collections = s.withinHorizon(collections, time.Now())
+	if len(collections) == 0 {
+		// An empty VCALENDAR stays the default so existing subscriptions keep
+		// working; clients that prefer no body can opt into a 204.
+		w.Header().Set("X-Empty-Schedule", "true")
+		if r.URL.Query().Get("empty") == "204" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+
+	payload, err := addr.calendar.Build(collections)
+	if err != nil {
+		s.loggerFor(ctx).Error("calendar build failed", slog.String("error", err.Error()))
+		writeProblem(w, r, http.StatusInternalServerError, "calendar_failed", "The calendar could not be built.")
+		return
+	}
+
+	etag := payloadETag(payload)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", cacheControlICS)
+	lastModified := addr.cache.FetchedAt()
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// Browsers render text/calendar inline; ?download=1 asks them to save it.
+	// Subscription clients never send it, so they see no Content-Disposition.
+	if download, _ := strconv.ParseBool(r.URL.Query().Get("download")); download {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", s.calendarFilename(".ics")))
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(payload); err != nil {
+		s.loggerFor(ctx).Warn("failed to write response", slog.String("error", err.Error()))
+	}
+}
+
+// freeBusyHandler serves the collections as a VFREEBUSY, optionally bounded
+// by dtstart/dtend.
+func (s *Server) freeBusyHandler(w http.ResponseWriter, r *http.Request) {
+	builder, ok := s.calendar.(FreeBusyBuilder)
+	if !ok {
+		writeProblem(w, r, http.StatusNotImplemented, "freebusy_unsupported", "The calendar builder does not support free/busy feeds.")
+		return
+	}
+
+	var bounds [2]time.Time
+	for i, key := range []string{"dtstart", "dtend"} {
+		input := strings.TrimSpace(r.URL.Query().Get(key))
+		if input == "" {
+			continue
+		}
+		parsed, err := s.parseTime(input)
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "invalid_range", "dtstart and dtend must be YYYY-MM-DD dates or RFC 3339 timestamps.")
+			return
+		}
+		bounds[i] = parsed
+	}
+	from, to := bounds[0], bounds[1]
+	if !from.IsZero() && !to.IsZero() && !to.After(from) {
+		writeProblem(w, r, http.StatusBadRequest, "invalid_range", "dtend must be after dtstart.")
+		return
+	}
+
+	addr, ok := s.resolveAddress(w, r)
+	if !ok {
+		return
+	}
+	collections, err := s.collectionsFor(r.Context(), w, addr)
+	if err != nil {
+		s.respondScrapeError(w, r, err)
+		return
+	}
+	collections = filterTypes(collections, r.URL.Query().Get("types"))
+
+	payload, err := builder.FreeBusy(collections, from, to)
+	if err != nil {
+		s.loggerFor(r.Context()).Error("freebusy build failed", slog.String("error", err.Error()))
+		writeProblem(w, r, http.StatusInternalServerError, "calendar_failed", "The calendar could not be built.")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", cacheControlICS)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(payload); err != nil {
+		s.loggerFor(r.Context()).Warn("failed to write response", slog.String("error", err.Error()))
+	}
+}
+
+func (s *Server) webcalHandler(w http.ResponseWriter, r *http.Request) {
+	target := url.URL{
+		Scheme:   "webcal",
+		Host:     requestHost(r),
+		Path:     "/calendar.ics",
+		RawQuery: r.URL.RawQuery,
+	}
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+// feedsHandler describes the available subscription URLs. It never scrapes:
+// per-type feeds are listed only for types already in the cache.
+func (s *Server) feedsHandler(w http.ResponseWriter, r *http.Request) {
+	addr, ok := s.resolveAddress(w, r)
+	if !ok {
+		return
+	}
+
+	query := url.Values{}
+	if addr != s.primary {
+		query.Set("uprn", addr.uprn)
+	}
+	// Subscription URLs cannot send headers, so carry a query token through.
+	if token := r.URL.Query().Get("token"); token != "" {
+		query.Set("token", token)
+	}
+	feedURL := func(scheme, path string, q url.Values) string {
+		u := url.URL{Scheme: scheme, Host: requestHost(r), Path: path, RawQuery: q.Encode()}
+		return u.String()
+	}
+
+	scheme := requestScheme(r)
+	var types []map[string]string
+	if cached, ok := addr.cache.Stale(); ok {
+		for _, wasteType := range uniqueTypes(cached) {
+			q := url.Values{"types": {wasteType}}
+			for k, v := range query {
+				q[k] = v
+			}
+			types = append(types, map[string]string{
+				"type":   wasteType,
+				"ics":    feedURL(scheme, "/calendar.ics", q),
+				"webcal": feedURL("webcal", "/calendar.ics", q),
+			})
+		}
+	}
+	if types == nil {
+		types = []map[string]string{}
+	}
+
+	setJSONCacheControl(w, r)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"name":        s.cfg.CalendarName,
+		"description": s.cfg.CalendarDesc,
+		"ics":         feedURL(scheme, "/calendar.ics", query),
+		"webcal":      feedURL("webcal", "/calendar.ics", query),
+		"types":       types,
+	})
+}
+
+// addressesHandler lists the properties at a postcode so users can find
+// their UPRN.
+func (s *Server) addressesHandler(w http.ResponseWriter, r *http.Request) {
+	lookup, ok := s.primary.scraper.(AddressLookup)
+	if !ok {
+		writeProblem(w, r, http.StatusNotImplemented, "lookup_unsupported", "The scraper cannot look up addresses.")
+		return
+	}
+
+	matches, err := lookup.LookupAddresses(r.Context(), r.URL.Query().Get("postcode"))
+	switch {
+	case errors.Is(err, scraper.ErrInvalidPostcode):
+		writeProblem(w, r, http.StatusBadRequest, "invalid_postcode", "The postcode parameter must be a valid UK postcode.")
+		return
+	case errors.Is(err, scraper.ErrNoAddresses):
+		writeProblem(w, r, http.StatusNotFound, "no_addresses", "The council returned no addresses for that postcode.")
+		return
+	case err != nil:
+		s.loggerFor(r.Context()).Error("address lookup failed", slog.String("error", err.Error()))
+		writeProblem(w, r, http.StatusBadGateway, "lookup_failed", "The council address lookup could not be fetched.")
+		return
+	}
+
+	resp := make([]map[string]string, 0, len(matches))
+	for _, m := range matches {
+		resp = append(resp, map[string]string{
+			"uprn":    m.UPRN,
+			"address": m.AddressLine,
+		})
+	}
+	setJSONCacheControl(w, r)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// validateHandler checks that the council accepts the UPRN and address
+// settings by running only the SaveAddress handshake. It bypasses the cache
+// and breaker, so setup problems show up straight away.
+func (s *Server) validateHandler(w http.ResponseWriter, r *http.Request) {
+	addr, ok := s.resolveAddress(w, r)
+	if !ok {
+		return
+	}
+	validator, ok := addr.scraper.(AddressValidator)
+	if !ok {
+		writeProblem(w, r, http.StatusNotImplemented, "validate_unsupported", "The scraper cannot validate addresses.")
+		return
+	}
+
+	if err := validator.ValidateAddress(r.Context()); err != nil {
+		s.respondScrapeError(w, r, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"ok":   true,
+		"uprn": addr.uprn,
+	})
+}
+
+// debugHTMLHandler scrapes the schedule page and returns it unparsed, to tell
+// a changed layout apart from a failed scrape.
+func (s *Server) debugHTMLHandler(w http.ResponseWriter, r *http.Request) {
+	addr, ok := s.resolveAddress(w, r)
+	if !ok {
+		return
+	}
+	fetcher, ok := addr.scraper.(HTMLFetcher)
+	if !ok {
+		writeProblem(w, r, http.StatusNotImplemented, "html_unsupported", "The scraper cannot return the raw schedule page.")
+		return
+	}
+
+	body, err := fetcher.FetchHTML(r.Context())
+	if err != nil {
+		s.respondScrapeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		s.loggerFor(r.Context()).Warn("failed to write response", slog.String("error", err.Error()))
+	}
+}
+
+// eventsHandler returns the calendar feed's events as JSON, honouring the
+// same types filter and horizon as /calendar.ics.
+func (s *Server) eventsHandler(w http.ResponseWriter, r *http.Request) {
+	addr, ok := s.resolveAddress(w, r)
+	if !ok {
+		return
+	}
+	lister, ok := addr.calendar.(EventLister)
+	if !ok {
+		writeProblem(w, r, http.StatusNotImplemented, "events_unsupported", "The calendar builder does not support event listings.")
+		return
+	}
+
+	collections, err := s.collectionsFor(r.Context(), w, addr)
+	if err != nil {
+		s.respondUnavailable(w, r, err)
+		return
+	}
+	s.writeEvents(w, r, lister, filterTypes(collections, r.URL.Query().Get("types")))
+}
+
+// writeEvents renders collections as the JSON event list, honouring HORIZON.
+func (s *Server) writeEvents(w http.ResponseWriter, r *http.Request, lister EventLister, collections []scraper.Collection) {
+	collections = s.withinHorizon(collections, time.Now())
+	events, err := lister.Events(collections)
+	if err != nil {
+		s.loggerFor(r.Context()).Error("calendar build failed", slog.String("error", err.Error()))
+		writeProblem(w, r, http.StatusInternalServerError, "calendar_failed", "The calendar could not be built.")
+		return
+	}
+
+	resp := make([]map[string]interface{}, 0, len(events))
+	for _, e := range events {
+		item := map[string]interface{}{
+			"uid":        e.UID,
+			"summary":    e.Summary,
+			"start":      e.Start.Format(time.RFC3339),
+			"end":        e.End.Format(time.RFC3339),
+			"all_day":    e.AllDay,
+			"categories": e.Categories,
+			"alarms":     e.Alarms,
+		}
+		if e.Rule != "" {
+			item["rrule"] = e.Rule
+		}
+		if e.Status != "" {
+			item["status"] = e.Status
+		}
+		resp = append(resp, item)
+	}
+	setJSONCacheControl(w, r)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// requestScheme returns the client-facing scheme, preferring
+// X-Forwarded-Proto when the service sits behind a reverse proxy.
+func requestScheme(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-Proto"); forwarded != "" {
+		return strings.ToLower(strings.TrimSpace(strings.Split(forwarded, ",")[0]))
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// requestHost returns the client-facing host, preferring X-Forwarded-Host
+// when the service sits behind a reverse proxy.
+func requestHost(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-Host"); forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	return r.Host
+}
+
+func payloadETag(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return fmt.Sprintf(`"%x"`, sum[:16])
+}
+
+// notModified reports whether the request's conditional headers match the
+// current representation. If-None-Match takes precedence over If-Modified-Since.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// notModifiedSinceFetch answers If-Modified-Since with 304 straight from the
+// cache's fetch time, before any collections are copied or serialized. It
+// only applies while the cache is fresh, as an expired one would be
+// re-scraped and may have changed, and to fetches from today: both responses
+// also depend on the date, through days_until and HORIZON. If-None-Match
+// takes precedence, so requests carrying one fall through to the full ETag
+// comparison.
+func (s *Server) notModifiedSinceFetch(w http.ResponseWriter, r *http.Request, addr *address, cacheControl string) bool {
+	if r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") == "" || r.URL.Query().Has("now") {
+		return false
+	}
+	fetched, ok := addr.cache.FreshSince(s.cfg.CacheTTL)
+	if !ok || !sameDay(fetched, time.Now(), s.location) || !notModified(r, "", fetched) {
+		return false
+	}
+	w.Header().Set("Last-Modified", fetched.UTC().Format(http.TimeFormat))
+	w.Header().Set("Cache-Control", cacheControl)
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+func (s *Server) nextHandler(w http.ResponseWriter, r *http.Request) {
+	loc, ok := s.resolveLocation(w, r)
+	if !ok {
+		return
+	}
+	now, ok := s.resolveNowIn(w, r, loc)
+	if !ok {
+		return
+	}
+
+	addr, ok := s.resolveAddress(w, r)
+	if !ok {
+		return
+	}
+
+	collections, err := s.collectionsFor(r.Context(), w, addr)
+	if err != nil {
+		s.respondUnavailable(w, r, err)
+		return
+	}
+
+	includeToday := true
+	if raw := r.URL.Query().Get("include_today"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "invalid_include_today", "The include_today parameter must be true or false.")
+			return
+		}
+		includeToday = parsed
+	}
+
+	day, found := nextDay(now, collections, loc, s.todayWindow(), includeToday)
+	if !found {
+		writeProblem(w, r, http.StatusNotFound, "no_upcoming_collections", "No collections are scheduled after the requested time.")
+		return
+	}
+
+	days := daysBetween(now, day.Date, loc)
+	resp := map[string]interface{}{
+		"date":  day.Date.In(loc).Format("2006-01-02"),
+		"days":  days,
+		"types": day.Types,
+	}
+	setJSONCacheControl(w, r)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// nextTypeHandler answers "when is my next <type> collection" for a single
+// type slug, e.g. /api/next/garden-waste.
+func (s *Server) nextTypeHandler(w http.ResponseWriter, r *http.Request) {
+	now, ok := s.resolveNow(w, r)
+	if !ok {
+		return
+	}
+
+	addr, ok := s.resolveAddress(w, r)
+	if !ok {
+		return
+	}
+
+	collections, err := s.collectionsFor(r.Context(), w, addr)
+	if err != nil {
+		s.respondUnavailable(w, r, err)
+		return
+	}
+
+	name := r.PathValue("type")
+	day, found := nextDay(now, filterSlug(collections, name), s.location, s.todayWindow(), true)
+	if !found {
+		writeProblem(w, r, http.StatusNotFound, "no_upcoming_collections", fmt.Sprintf("No %q collections are scheduled after the requested time.", name))
+		return
+	}
+
+	setJSONCacheControl(w, r)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"date": day.Date.In(s.location).Format("2006-01-02"),
+		"days": daysBetween(now, day.Date, s.location),
+	})
+}
+
+// countdownHandler reports the seconds until the next collection starts, for
+// automations that want a number rather than a date. It is zero while a
+// collection is in progress.
+func (s *Server) countdownHandler(w http.ResponseWriter, r *http.Request) {
+	now, ok := s.resolveNow(w, r)
+	if !ok {
+		return
+	}
+
+	addr, ok := s.resolveAddress(w, r)
+	if !ok {
+		return
+	}
+
+	collections, err := s.collectionsFor(r.Context(), w, addr)
+	if err != nil {
+		s.respondUnavailable(w, r, err)
+		return
+	}
+
+	day, found := nextDay(now, collections, s.location, s.todayWindow(), true)
+	if !found {
+		writeProblem(w, r, http.StatusNotFound, "no_upcoming_collections", "No collections are scheduled after the requested time.")
+		return
+	}
+
+	seconds := int64(day.Date.Sub(now) / time.Second)
+	if seconds < 0 {
+		seconds = 0
+	}
+	setJSONCacheControl(w, r)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"seconds": seconds,
+		"date":    day.Date.In(s.location).Format("2006-01-02"),
+		"types":   day.Types,
+	})
+}
+
+func (s *Server) weekHandler(w http.ResponseWriter, r *http.Request) {
+	now, ok := s.resolveNow(w, r)
+	if !ok {
+		return
+	}
+
+	addr, ok := s.resolveAddress(w, r)
+	if !ok {
+		return
+	}
+
+	collections, err := s.collectionsFor(r.Context(), w, addr)
+	if err != nil {
+		s.respondUnavailable(w, r, err)
+		return
+	}
+
+	resp := []map[string]interface{}{}
+	for _, day := range weekDays(now, collections, s.todayWindow()) {
+		resp = append(resp, map[string]interface{}{
+			"date":  day.Date.In(s.location).Format("2006-01-02"),
+			"days":  daysBetween(now, day.Date, s.location),
+			"types": day.Types,
+		})
+	}
+	setJSONCacheControl(w, r)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) scheduleHandler(w http.ResponseWriter, r *http.Request) {
+	now, ok := s.resolveNow(w, r)
+	if !ok {
+		return
+	}
+	offset, limit, paged, ok := resolvePage(w, r)
+	if !ok {
+		return
+	}
+
+	addr, ok := s.resolveAddress(w, r)
+	if !ok {
+		return
+	}
+	if s.notModifiedSinceFetch(w, r, addr, cacheControlJSON) {
+		return
+	}
+
+	collections, err := s.collectionsFor(r.Context(), w, addr)
+	if err != nil {
+		s.respondUnavailable(w, r, err)
+		return
+	}
+	collections = s.withinHorizon(collections, now)
+
+	frequencies := make(map[string]string)
+	for _, c := range collections {
+		frequencies[c.Type] = c.Frequency
+	}
+
+	resp := []map[string]interface{}{}
+	for _, day := range groupDays(collections) {
+		dayFrequencies := make(map[string]string, len(day.Types))
+		for _, t := range day.Types {
+			dayFrequencies[t] = frequencies[t]
+		}
+		resp = append(resp, map[string]interface{}{
+			"date":        day.Date.In(s.location).Format("2006-01-02"),
+			"types":       day.Types,
+			"frequencies": dayFrequencies,
+			"note":        day.Note,
+			"days_until":  daysBetween(now, day.Date, s.location),
+		})
+	}
+	if fetched := addr.cache.FetchedAt(); !fetched.IsZero() {
+		w.Header().Set("Last-Modified", fetched.UTC().Format(http.TimeFormat))
+	}
+	garden := scraper.GardenUnknown
+	if reporter, ok := addr.scraper.(GardenReporter); ok {
+		garden = reporter.GardenStatus()
+	}
+	w.Header().Set("X-Garden-Status", string(garden))
+	setJSONCacheControl(w, r)
+	if !paged {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	total := len(resp)
+	start := min(offset, total)
+	end := total
+	if limit > 0 {
+		end = min(start+limit, total)
+	}
+	var nextOffset interface{}
+	if end < total {
+		nextOffset = end
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"days":          resp[start:end],
+		"total":         total,
+		"next_offset":   nextOffset,
+		"garden_status": garden,
+	})
+}
+
+// resolvePage reads ?offset= and ?limit= for paging through /api/schedule.
+// paged is false when neither is given, so the response keeps its original
+// bare-array shape; a limit of 0 means everything from offset on.
+func resolvePage(w http.ResponseWriter, r *http.Request) (offset, limit int, paged, ok bool) {
+	q := r.URL.Query()
+	if !q.Has("offset") && !q.Has("limit") {
+		return 0, 0, false, true
+	}
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{{"offset", &offset}, {"limit", &limit}} {
+		raw := q.Get(p.name)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			writeProblem(w, r, http.StatusBadRequest, "invalid_page", "offset and limit must be non-negative integers.")
+			return 0, 0, false, false
+		}
+		*p.dst = n
+	}
+	return offset, limit, true, true
+}
+
+func (s *Server) typesHandler(w http.ResponseWriter, r *http.Request) {
+	loc, ok := s.resolveLocation(w, r)
+	if !ok {
+		return
+	}
+	now, ok := s.resolveNowIn(w, r, loc)
+	if !ok {
+		return
+	}
 
-func (s *Server) calendarHandler(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	collections, err := s.collections(ctx)
-	if err != nil {
-		s.respondScrapeError(w, err)
+	addr, ok := s.resolveAddress(w, r)
+	if !ok {
 		return
 	}
 
-	payload, err := s.calendar.Build(collections)
+	collections, err := s.collectionsFor(r.Context(), w, addr)
 	if err != nil {
-		s.logger.Error("calendar build failed", slog.String("error", err.Error()))
-		writeJSON(w, http.StatusInternalServerError, map[string]string{
-			"error": "calendar_failed",
-		})
+		s.respondUnavailable(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
-	w.Header().Set("Cache-Control", cacheControlICS)
-	w.WriteHeader(http.StatusOK)
-	if _, err := w.Write(payload); err != nil {
-		s.logger.Warn("failed to write response", slog.String("error", err.Error()))
+	todayTypes := today(now, collections, loc, s.todayWindow())
+	tomorrowTypes := tomorrow(now, collections, loc)
+	// Cancelled weeks carry a suffixed type; they aren't a separate stream.
+	allTypes := uniqueTypes(withoutSkipped(collections))
+	if allTypes == nil {
+		allTypes = []string{}
+	}
+
+	resp := map[string]interface{}{
+		"today":    todayTypes,
+		"tomorrow": tomorrowTypes,
+		"all":      allTypes,
 	}
+	setJSONCacheControl(w, r)
+	writeJSON(w, http.StatusOK, resp)
 }
 
-func (s *Server) nextHandler(w http.ResponseWriter, r *http.Request) {
-	now, ok := s.resolveNow(w, r.URL.Query())
+func (s *Server) isTodayHandler(w http.ResponseWriter, r *http.Request) {
+	loc, ok := s.resolveLocation(w, r)
+	if !ok {
+		return
+	}
+	now, ok := s.resolveNowIn(w, r, loc)
 	if !ok {
 		return
 	}
 
-	collections, err := s.collections(r.Context())
-	if err != nil {
-		s.respondUnavailable(w, err)
+	addr, ok := s.resolveAddress(w, r)
+	if !ok {
 		return
 	}
 
-	day, found := nextDay(now, collections, s.location)
-	if !found {
-		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no_upcoming_collections"})
+	collections, err := s.collectionsFor(r.Context(), w, addr)
+	if err != nil {
+		s.respondUnavailable(w, r, err)
 		return
 	}
+	s.refreshIfOlder(addr, s.cfg.MinFreshness)
 
-	days := daysBetween(now, day.Date, s.location)
+	types := today(now, collections, loc, s.todayWindow())
 	resp := map[string]interface{}{
-		"date":  day.Date.In(s.location).Format("2006-01-02"),
-		"days":  days,
-		"types": day.Types,
+		"today": len(types) > 0,
+		"types": types,
 	}
+	setJSONCacheControl(w, r)
 	writeJSON(w, http.StatusOK, resp)
 }
 
-func (s *Server) typesHandler(w http.ResponseWriter, r *http.Request) {
-	now, ok := s.resolveNow(w, r.URL.Query())
+func (s *Server) isTomorrowHandler(w http.ResponseWriter, r *http.Request) {
+	loc, ok := s.resolveLocation(w, r)
+	if !ok {
+		return
+	}
+	now, ok := s.resolveNowIn(w, r, loc)
 	if !ok {
 		return
 	}
 
-	collections, err := s.collections(r.Context())
-	if err != nil {
-		s.respondUnavailable(w, err)
+	addr, ok := s.resolveAddress(w, r)
+	if !ok {
 		return
 	}
 
-	todayTypes := today(now, collections, s.location)
-	tomorrowTypes := tomorrow(now, collections, s.location)
+	collections, err := s.collectionsFor(r.Context(), w, addr)
+	if err != nil {
+		s.respondUnavailable(w, r, err)
+		return
+	}
+	s.refreshIfOlder(addr, s.cfg.MinFreshness)
 
+	types := tomorrow(now, collections, loc)
 	resp := map[string]interface{}{
-		"today":    todayTypes,
-		"tomorrow": tomorrowTypes,
+		"tomorrow": len(types) > 0,
+		"types":    types,
 	}
+	setJSONCacheControl(w, r)
 	writeJSON(w, http.StatusOK, resp)
 }
 
-func (s *Server) isTodayHandler(w http.ResponseWriter, r *http.Request) {
-	now, ok := s.resolveNow(w, r.URL.Query())
+// soonHandler answers whether any collection falls within ?within= (default
+// 2d), for automations that want more warning than is-tomorrow gives.
+func (s *Server) soonHandler(w http.ResponseWriter, r *http.Request) {
+	loc, ok := s.resolveLocation(w, r)
+	if !ok {
+		return
+	}
+	now, ok := s.resolveNowIn(w, r, loc)
+	if !ok {
+		return
+	}
+	within := strings.TrimSpace(r.URL.Query().Get("within"))
+	if within == "" {
+		within = "2d"
+	}
+	days, span, err := parseWithin(within)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid_within", "within must be a number of days such as 2d or a duration such as 36h.")
+		return
+	}
+
+	addr, ok := s.resolveAddress(w, r)
 	if !ok {
 		return
 	}
 
-	collections, err := s.collections(r.Context())
+	collections, err := s.collectionsFor(r.Context(), w, addr)
 	if err != nil {
-		s.respondUnavailable(w, err)
+		s.respondUnavailable(w, r, err)
 		return
 	}
+	s.refreshIfOlder(addr, s.cfg.MinFreshness)
 
-	types := today(now, collections, s.location)
-	resp := map[string]interface{}{
-		"today": len(types) > 0,
-		"types": types,
+	window := s.todayWindow()
+	types := []string{}
+	upcoming := []map[string]interface{}{}
+	for _, day := range groupDays(withoutSkipped(collections)) {
+		if now.After(window.end(day.Date)) {
+			continue
+		}
+		until := daysBetween(now, day.Date, loc)
+		if (span > 0 && day.Date.After(now.Add(span))) || (span == 0 && until > days) {
+			break
+		}
+		for _, t := range day.Types {
+			if !contains(types, t) {
+				types = append(types, t)
+			}
+		}
+		upcoming = append(upcoming, map[string]interface{}{
+			"date":       day.Date.In(loc).Format("2006-01-02"),
+			"types":      day.Types,
+			"days_until": until,
+		})
 	}
-	writeJSON(w, http.StatusOK, resp)
+
+	setJSONCacheControl(w, r)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"soon":   len(upcoming) > 0,
+		"within": within,
+		"types":  types,
+		"days":   upcoming,
+	})
 }
 
-func (s *Server) isTomorrowHandler(w http.ResponseWriter, r *http.Request) {
-	now, ok := s.resolveNow(w, r.URL.Query())
+// parseWithin reads a soon window: "Nd" counts whole calendar days after
+// today (so 2d covers today, tomorrow and the day after), anything else is a
+// Go duration measured from now to a collection's start.
+func parseWithin(value string) (days int, span time.Duration, err error) {
+	if n, ok := strings.CutSuffix(value, "d"); ok {
+		days, err = strconv.Atoi(n)
+		if err != nil || days < 0 {
+			return 0, 0, fmt.Errorf("invalid day count %q", value)
+		}
+		return days, 0, nil
+	}
+	span, err = time.ParseDuration(value)
+	if err != nil || span <= 0 {
+		return 0, 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return 0, span, nil
+}
+
+func (s *Server) refreshHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.ipAllowed(r) {
+		s.loggerFor(r.Context()).Warn("refresh denied", slog.String("client_ip", s.clientIP(r)))
+		writeProblem(w, r, http.StatusForbidden, "forbidden", "This client is not allowed to trigger a refresh.")
+		return
+	}
+	addr, ok := s.resolveAddress(w, r)
 	if !ok {
 		return
 	}
+	if !s.allowRefresh() {
+		writeProblem(w, r, http.StatusTooManyRequests, "refresh_rate_limited", "A refresh ran too recently; try again shortly.")
+		return
+	}
 
-	collections, err := s.collections(r.Context())
+	collections, err := s.collections(r.Context(), addr, true)
 	if err != nil {
-		s.respondUnavailable(w, err)
+		s.respondScrapeError(w, r, err)
 		return
 	}
 
-	types := tomorrow(now, collections, s.location)
-	resp := map[string]interface{}{
-		"tomorrow": len(types) > 0,
-		"types":    types,
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"refreshed": true,
+		"items":     len(collections),
+	})
+}
+
+// allowRefresh permits at most one forced refresh per refreshInterval.
+func (s *Server) allowRefresh() bool {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+	now := time.Now()
+	if !s.lastRefresh.IsZero() && now.Sub(s.lastRefresh) < refreshInterval {
+		return false
 	}
-	writeJSON(w, http.StatusOK, resp)
+	s.lastRefresh = now
+	return true
+}
+
+// resolveAddress picks the address named by the uprn query parameter, falling
+// back to the primary address when it is absent.
+func (s *Server) resolveAddress(w http.ResponseWriter, r *http.Request) (*address, bool) {
+	uprn := strings.TrimSpace(r.URL.Query().Get("uprn"))
+	if uprn == "" {
+		return s.primary, true
+	}
+	addr, ok := s.addresses[uprn]
+	if !ok {
+		writeProblem(w, r, http.StatusNotFound, "unknown_uprn", fmt.Sprintf("UPRN %q is not configured.", uprn))
+		return nil, false
+	}
+	return addr, true
 }
 
-func (s *Server) collections(ctx context.Context) ([]scraper.Collection, error) {
-	if items, ok := s.cache.Get(s.cfg.CacheTTL); ok {
-		s.logger.Info("cache hit", slog.Int("items", len(items)))
+// collectionsFor loads collections for a handler. When scraping fails and
+// stale data is allowed, the expired cache is served and the response is
+// flagged with X-Data-Stale.
+func (s *Server) collectionsFor(ctx context.Context, w http.ResponseWriter, addr *address) ([]scraper.Collection, error) {
+	items, err := s.collections(ctx, addr, false)
+	if err == nil || !s.cfg.ServeStaleOnError {
+		return items, err
+	}
+
+	stale, ok := addr.cache.Stale()
+	if !ok {
+		return nil, err
+	}
+	s.loggerFor(ctx).Warn("serving stale collections",
+		slog.String("uprn", addr.uprn),
+		slog.String("error", err.Error()),
+		slog.Time("fetched", addr.cache.FetchedAt()),
+	)
+	w.Header().Set("X-Data-Stale", "true")
+	return stale, nil
+}
+
+// collections returns cached collections when fresh, scraping otherwise.
+// force bypasses the cache and always scrapes.
+func (s *Server) collections(ctx context.Context, addr *address, force bool) ([]scraper.Collection, error) {
+	logger := s.loggerFor(ctx)
+	if items, ok := addr.cache.Get(s.cfg.CacheTTL); ok && !force {
+		logger.Info("cache hit", slog.Int("items", len(items)))
 		if s.metrics != nil {
 			s.metrics.cacheHits.Inc()
 		}
@@ -225,67 +1305,264 @@ func (s *Server) collections(ctx context.Context) ([]scraper.Collection, error)
 
 	if s.metrics != nil {
 		s.metrics.cacheMisses.Inc()
+	}
+	return s.sharedScrape(ctx, addr)
+}
+
+// sharedScrape scrapes addr, sharing one scrape between concurrent callers
+// for the same UPRN. The scrape runs detached from any one caller's context,
+// bounded by scrapeTimeout, so a caller that gives up only stops waiting:
+// the scrape carries on for everyone else.
+func (s *Server) sharedScrape(ctx context.Context, addr *address) ([]scraper.Collection, error) {
+	ch := s.flights.DoChan(addr.uprn, func() (interface{}, error) {
+		scrapeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.scrapeTimeout())
+		defer cancel()
+		return s.scrape(scrapeCtx, addr)
+	})
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.([]scraper.Collection), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// scrapeTimeout bounds a shared scrape: both requests of the SaveAddress
+// handshake and schedule fetch, each retried up to cfg.MaxRetries times.
+func (s *Server) scrapeTimeout() time.Duration {
+	if s.cfg.RequestTimeout <= 0 {
+		return defaultScrapeTimeout
+	}
+	attempts := time.Duration(s.cfg.MaxRetries + 1)
+	return 2 * attempts * s.cfg.RequestTimeout
+}
+
+// scrape fetches fresh collections for addr and caches them. Callers go
+// through sharedScrape so concurrent cache misses share one scrape.
+func (s *Server) scrape(ctx context.Context, addr *address) ([]scraper.Collection, error) {
+	logger := s.loggerFor(ctx)
+	if err := addr.breaker.allow(time.Now()); err != nil {
+		return nil, err
+	}
+	if s.metrics != nil {
 		s.metrics.scrapeRequests.Inc()
 	}
 
 	start := time.Now()
-	s.logger.Info("scrape start")
-	items, err := s.scraper.FetchCollections(ctx)
+	logger.Info("scrape start", slog.String("uprn", addr.uprn))
+	items, err := addr.scraper.FetchCollections(ctx)
+	// ctx is detached from callers (see sharedScrape), so every outcome is
+	// real: a scrape that runs out of scrapeTimeout counts as a failure,
+	// reopening the breaker if it was the half-open probe.
+	if addr.breaker.record(err, time.Now()) {
+		logger.Warn("circuit breaker opened",
+			slog.Int("failures", s.cfg.BreakerThreshold),
+			slog.Duration("cooldown", s.cfg.BreakerCooldown),
+		)
+	}
 	if err != nil {
 		if s.metrics != nil {
 			s.metrics.scrapeFailures.Inc()
 		}
+		s.recordScrape(addr, err)
 		return nil, err
 	}
 	duration := time.Since(start)
-	s.logger.Info("scrape complete", slog.Int("items", len(items)), slog.Duration("took", duration))
+	parsers := parsersUsed(items)
+	logger.Info("scrape complete",
+		slog.Int("items", len(items)),
+		slog.Duration("took", duration),
+		slog.String("parser_used", strings.Join(parsers, ",")),
+	)
 
 	if s.metrics != nil {
+		for _, parser := range parsers {
+			s.metrics.parserUsed.WithLabelValues(parser).Inc()
+		}
 		s.metrics.scrapeDuration.Observe(duration.Seconds())
 		s.metrics.lastScrapeTime.Set(float64(time.Now().Unix()))
+		s.metrics.observeCollections(addr.uprn, items)
 	}
 
-	s.cache.Set(items)
+	s.recordScrape(addr, nil)
+	if previous, ok := addr.cache.Stale(); ok {
+		s.logScheduleChanges(logger, addr, previous, items)
+	}
+	if err := addr.cache.Set(items); err != nil {
+		logger.Warn("cache persist failed", slog.String("error", err.Error()))
+	}
 	return items, nil
 }
 
-func (s *Server) respondScrapeError(w http.ResponseWriter, err error) {
-	s.logger.Error("scrape failed", slog.String("error", err.Error()))
-	code := http.StatusBadGateway
-	detail := "scrape_failed"
+// refreshIfOlder starts a background scrape for addr when its cache is older
+// than maxAge, without delaying the current response. At most one background
+// refresh runs per address at a time.
+func (s *Server) refreshIfOlder(addr *address, maxAge time.Duration) {
+	if maxAge <= 0 {
+		return
+	}
+	fetched := addr.cache.FetchedAt()
+	if fetched.IsZero() || time.Since(fetched) <= maxAge {
+		return
+	}
+	if !s.shouldScrape(time.Now()) {
+		return
+	}
+	if !addr.refreshing.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer addr.refreshing.Store(false)
+		if _, err := s.collections(context.Background(), addr, true); err != nil {
+			s.logger.Warn("background refresh failed", slog.String("uprn", addr.uprn), slog.String("error", err.Error()))
+		}
+	}()
+}
+
+func (s *Server) recordScrape(addr *address, err error) {
+	s.healthMu.Lock()
+	if err != nil {
+		s.lastError = err.Error()
+	} else {
+		s.lastSuccess = time.Now()
+		s.lastError = ""
+	}
+	s.healthMu.Unlock()
+	s.webhook.record(addr.uprn, err, addr.cache.FetchedAt())
+}
+
+func (s *Server) respondScrapeError(w http.ResponseWriter, r *http.Request, err error) {
+	s.loggerFor(r.Context()).Error("scrape failed", slog.String("error", err.Error()))
+	code, detail := "scrape_failed", "The council schedule could not be fetched."
 	if errors.Is(err, scraper.ErrNoCollections) {
-		detail = "failed_to_parse_schedule"
+		code, detail = "failed_to_parse_schedule", "The council page did not contain a recognisable schedule."
 	}
 	if errors.Is(err, scraper.ErrAddressSetup) {
-		detail = "address_setup_failed"
+		code, detail = "address_setup_failed", "The council site rejected the configured address."
 	}
-	writeJSON(w, code, map[string]string{"error": detail})
+	if errors.Is(err, scraper.ErrBodyTooLarge) {
+		code, detail = "response_too_large", "The council site returned more data than the configured limit."
+	}
+	writeProblem(w, r, http.StatusBadGateway, code, detail)
+}
+
+func (s *Server) respondUnavailable(w http.ResponseWriter, r *http.Request, err error) {
+	s.loggerFor(r.Context()).Error("collections unavailable", slog.String("error", err.Error()))
+	writeProblem(w, r, http.StatusServiceUnavailable, "unavailable", "No collection data is available yet.")
 }
 
-func (s *Server) respondUnavailable(w http.ResponseWriter, err error) {
-	s.logger.Error("collections unavailable", slog.String("error", err.Error()))
-	writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "unavailable"})
+func (s *Server) resolveNow(w http.ResponseWriter, r *http.Request) (time.Time, bool) {
+	return s.resolveNowIn(w, r, s.location)
 }
 
-func (s *Server) resolveNow(w http.ResponseWriter, values url.Values) (time.Time, bool) {
-	now := time.Now().In(s.location)
-	input := strings.TrimSpace(values.Get("now"))
+// resolveNowIn is resolveNow in loc: a date-only override means midnight
+// there, and the result is expressed there.
+func (s *Server) resolveNowIn(w http.ResponseWriter, r *http.Request, loc *time.Location) (time.Time, bool) {
+	now := time.Now().In(loc)
+	input := strings.TrimSpace(r.URL.Query().Get("now"))
 	if input == "" {
 		return now, true
 	}
 
-	parsed, err := time.Parse(time.RFC3339, input)
+	parsed, err := parseTimeIn(input, loc)
 	if err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_now"})
+		writeProblem(w, r, http.StatusBadRequest, "invalid_now", "The now parameter must be a YYYY-MM-DD date or an RFC 3339 timestamp.")
 		return time.Time{}, false
 	}
+	return parsed, true
+}
 
-	return parsed.In(s.location), true
+// parseTime reads a date-only value as midnight in the server's zone, or an
+// RFC 3339 timestamp.
+func (s *Server) parseTime(input string) (time.Time, error) {
+	return parseTimeIn(input, s.location)
 }
 
-func today(now time.Time, collections []scraper.Collection, loc *time.Location) []string {
-	for _, day := range groupDays(collections) {
-		if sameDay(now, day.Date, loc) && now.Before(day.Date.Add(collectionDuration)) {
+func parseTimeIn(input string, loc *time.Location) (time.Time, error) {
+	if parsed, err := time.ParseInLocation("2006-01-02", input, loc); err == nil {
+		return parsed, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, input)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return parsed.In(loc), nil
+}
+
+// resolveLocation picks the zone named by the tz query parameter for day
+// comparisons, defaulting to the server's. Collection times themselves stay
+// in the server's zone; only which calendar day they fall on changes.
+func (s *Server) resolveLocation(w http.ResponseWriter, r *http.Request) (*time.Location, bool) {
+	name := strings.TrimSpace(r.URL.Query().Get("tz"))
+	if name == "" {
+		return s.location, true
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid_tz", fmt.Sprintf("Unknown time zone %q; use an IANA name such as America/New_York.", name))
+		return nil, false
+	}
+	return loc, true
+}
+
+func filterTypes(collections []scraper.Collection, raw string) []scraper.Collection {
+	wanted := map[string]struct{}{}
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		wanted[part] = struct{}{}
+	}
+	if len(wanted) == 0 {
+		return collections
+	}
+
+	var filtered []scraper.Collection
+	for _, c := range collections {
+		if _, ok := wanted[strings.ToLower(c.Type)]; ok {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+// filterSlug keeps the collections whose type slug, as used in event UIDs,
+// equals name.
+func filterSlug(collections []scraper.Collection, name string) []scraper.Collection {
+	var filtered []scraper.Collection
+	for _, c := range collections {
+		if calendar.Slug(c.Type) == name {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+// uniqueTypes returns the distinct waste types in collections, sorted.
+func uniqueTypes(collections []scraper.Collection) []string {
+	seen := map[string]struct{}{}
+	var types []string
+	for _, c := range collections {
+		if _, ok := seen[c.Type]; ok {
+			continue
+		}
+		seen[c.Type] = struct{}{}
+		types = append(types, c.Type)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// today returns the types collected on now's day whose collection window
+// has not yet passed.
+func today(now time.Time, collections []scraper.Collection, loc *time.Location, window collectionWindow) []string {
+	for _, day := range groupDays(withoutSkipped(collections)) {
+		if sameDay(now, day.Date, loc) && now.Before(window.end(day.Date)) {
 			return day.Types
 		}
 	}
@@ -294,7 +1571,7 @@ func today(now time.Time, collections []scraper.Collection, loc *time.Location)
 
 func tomorrow(now time.Time, collections []scraper.Collection, loc *time.Location) []string {
 	target := now.AddDate(0, 0, 1)
-	for _, day := range groupDays(collections) {
+	for _, day := range groupDays(withoutSkipped(collections)) {
 		if sameDay(target, day.Date, loc) {
 			return day.Types
 		}
@@ -302,19 +1579,81 @@ func tomorrow(now time.Time, collections []scraper.Collection, loc *time.Locatio
 	return []string{}
 }
 
-func nextDay(now time.Time, collections []scraper.Collection, loc *time.Location) (daySummary, bool) {
-	for _, day := range groupDays(collections) {
-		if now.Before(day.Date.Add(collectionDuration)) || sameDay(now, day.Date, loc) && !now.After(day.Date.Add(collectionDuration)) {
-			return day, true
+// nextDay returns the first collection day still to come. With includeToday,
+// a day stays "next" until its collection window (start plus window, so 07:00
+// by default) has passed; without it, only days after now's calendar day are
+// considered.
+func nextDay(now time.Time, collections []scraper.Collection, loc *time.Location, window collectionWindow, includeToday bool) (daySummary, bool) {
+	for _, day := range groupDays(withoutSkipped(collections)) {
+		if !includeToday {
+			if daysBetween(now, day.Date, loc) > 0 {
+				return day, true
+			}
+			continue
 		}
-		if day.Date.After(now) {
+		if !now.After(window.end(day.Date)) {
 			return day, true
 		}
 	}
 	return daySummary{}, false
 }
 
+// withoutSkipped drops cancelled entries so "is it bin day" answers ignore
+// them; the schedule endpoints still list them to explain the gap.
+func withoutSkipped(collections []scraper.Collection) []scraper.Collection {
+	var kept []scraper.Collection
+	for _, c := range collections {
+		if !c.Skipped() {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+func weekDays(now time.Time, collections []scraper.Collection, window collectionWindow) []daySummary {
+	end := now.AddDate(0, 0, 7)
+	var days []daySummary
+	for _, day := range groupDays(withoutSkipped(collections)) {
+		if !now.Before(window.end(day.Date)) || day.Date.After(end) {
+			continue
+		}
+		days = append(days, day)
+	}
+	return days
+}
+
+// collectionWindow decides until when a collection still counts as today's:
+// after for a while after it starts, and with an endHour until that hour on
+// its day, whichever is later.
+type collectionWindow struct {
+	after   time.Duration
+	endHour int
+	loc     *time.Location
+}
+
+// end returns when the collection starting at start stops counting as today's.
+func (w collectionWindow) end(start time.Time) time.Time {
+	end := start.Add(w.after)
+	if w.endHour > 0 {
+		local := start.In(w.loc)
+		if windowEnd := time.Date(local.Year(), local.Month(), local.Day(), w.endHour, 0, 0, 0, w.loc); windowEnd.After(end) {
+			end = windowEnd
+		}
+	}
+	return end
+}
+
+// todayWindow is the window from TODAY_WINDOW and COLLECTION_WINDOW_END.
+func (s *Server) todayWindow() collectionWindow {
+	after := collectionDuration
+	if s.cfg.TodayWindow > 0 {
+		after = s.cfg.TodayWindow
+	}
+	return collectionWindow{after: after, endHour: s.cfg.WindowEndHour, loc: s.location}
+}
+
 func daysBetween(from, to time.Time, loc *time.Location) int {
+	from, to = from.In(loc), to.In(loc)
 	fromDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
 	toDay := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, loc)
 	return int(toDay.Sub(fromDay).Hours() / 24)
@@ -329,6 +1668,7 @@ func sameDay(a, b time.Time, loc *time.Location) bool {
 type daySummary struct {
 	Date  time.Time
 	Types []string
+	Note  string
 }
 
 func groupDays(collections []scraper.Collection) []daySummary {
@@ -348,6 +1688,7 @@ func groupDays(collections []scraper.Collection) []daySummary {
 		if !contains(index[key].Types, c.Type) {
 			index[key].Types = append(index[key].Types, c.Type)
 		}
+		index[key].Note = mergeNote(index[key].Note, c.Note)
 	}
 
 	sort.Strings(keys)
@@ -358,6 +1699,32 @@ func groupDays(collections []scraper.Collection) []daySummary {
 	return days
 }
 
+func mergeNote(existing, extra string) string {
+	extra = strings.TrimSpace(extra)
+	if extra == "" || strings.Contains(existing, extra) {
+		return existing
+	}
+	if existing == "" {
+		return extra
+	}
+	return existing + "\n" + extra
+}
+
+// withinHorizon drops collections later than the configured HORIZON past now.
+func (s *Server) withinHorizon(collections []scraper.Collection, now time.Time) []scraper.Collection {
+	if s.cfg.Horizon <= 0 {
+		return collections
+	}
+	cutoff := now.Add(s.cfg.Horizon)
+	var filtered []scraper.Collection
+	for _, c := range collections {
+		if !c.Date.After(cutoff) {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
 func contains(list []string, value string) bool {
 	for _, v := range list {
 		if v == value {
@@ -367,6 +1734,41 @@ func contains(list []string, value string) bool {
 	return false
 }
 
+// setJSONCacheControl lets proxies briefly cache JSON answers, except when the
+// caller overrides now: those answers depend on the caller's input.
+func setJSONCacheControl(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Has("now") {
+		w.Header().Set("Cache-Control", "no-store")
+		return
+	}
+	w.Header().Set("Cache-Control", cacheControlJSON)
+}
+
+// problemTypeBase prefixes the stable error code to form a problem type URI.
+const problemTypeBase = "urn:redbridge-ics:problem:"
+
+// writeProblem reports an error as RFC 7807 application/problem+json. Clients
+// that still expect the old {"error":"code"} body can ask for ?format=legacy.
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	if r.URL.Query().Get("format") == "legacy" {
+		writeJSON(w, status, map[string]string{"error": code})
+		return
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"type":   problemTypeBase + code,
+		"title":  http.StatusText(status),
+		"status": status,
+		"detail": detail,
+	})
+	if err != nil {
+		http.Error(w, `{"error":"encode_failed"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
 func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	data, err := json.Marshal(payload)
@@ -382,10 +1784,38 @@ type collectionCache struct {
 	mu      sync.RWMutex
 	items   []scraper.Collection
 	fetched time.Time
+	path    string
+}
+
+// cacheSnapshot is the on-disk representation of a collectionCache.
+type cacheSnapshot struct {
+	Fetched time.Time            `json:"fetched"`
+	Items   []scraper.Collection `json:"items"`
 }
 
-func newCollectionCache() *collectionCache {
-	return &collectionCache{}
+// newCollectionCache returns a cache persisted to path when it is non-empty.
+// A snapshot already on disk is loaded if it is younger than ttl; missing or
+// corrupt files simply leave the cache empty.
+func newCollectionCache(path string, ttl time.Duration) *collectionCache {
+	c := &collectionCache{path: path}
+	if path == "" {
+		return c
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c
+	}
+	var snap cacheSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil || snap.Items == nil {
+		return c
+	}
+	if ttl <= 0 || time.Since(snap.Fetched) > ttl {
+		return c
+	}
+	c.items = snap.Items
+	c.fetched = snap.Fetched
+	return c
 }
 
 func (c *collectionCache) Get(ttl time.Duration) ([]scraper.Collection, bool) {
@@ -403,9 +1833,60 @@ func (c *collectionCache) Get(ttl time.Duration) ([]scraper.Collection, bool) {
 	return append([]scraper.Collection(nil), c.items...), true
 }
 
-func (c *collectionCache) Set(items []scraper.Collection) {
+// Stale returns the cached items regardless of age.
+func (c *collectionCache) Stale() ([]scraper.Collection, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.items == nil {
+		return nil, false
+	}
+	return append([]scraper.Collection(nil), c.items...), true
+}
+
+// FreshSince returns when the cache was populated if it is still within ttl,
+// without copying the items.
+func (c *collectionCache) FreshSince(ttl time.Duration) (time.Time, bool) {
+	if ttl <= 0 {
+		return time.Time{}, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.items == nil || time.Since(c.fetched) > ttl {
+		return time.Time{}, false
+	}
+	return c.fetched, true
+}
+
+// FetchedAt returns when the cache was last populated, or the zero time if empty.
+func (c *collectionCache) FetchedAt() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.fetched
+}
+
+// Set replaces the cached items. The in-memory cache is always updated; the
+// returned error only reports a failure to persist the snapshot to disk.
+func (c *collectionCache) Set(items []scraper.Collection) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.items = append([]scraper.Collection(nil), items...)
 	c.fetched = time.Now()
+	if c.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(cacheSnapshot{Fetched: c.fetched, Items: c.items})
+	if err != nil {
+		return fmt.Errorf("encode cache: %w", err)
+	}
+	// Write to a sibling temp file and rename so a crash never leaves a
+	// half-written snapshot behind.
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write cache: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("write cache: %w", err)
+	}
+	return nil
 }
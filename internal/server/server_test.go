@@ -1,11 +1,23 @@
 package server
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"io"
 	"log/slog"
+	"net/http"
 	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -33,12 +45,12 @@ func TestCollectionsCache(t *testing.T) {
 		Timezone:   "Europe/London",
 	}
 
-	srv := New(cfg, s, cal, logger)
+	srv := mustNew(t, cfg, s, cal, logger)
 
-	if _, err := srv.collections(context.Background()); err != nil {
+	if _, err := srv.collections(context.Background(), srv.primary, false); err != nil {
 		t.Fatalf("collections: %v", err)
 	}
-	if _, err := srv.collections(context.Background()); err != nil {
+	if _, err := srv.collections(context.Background(), srv.primary, false); err != nil {
 		t.Fatalf("collections: %v", err)
 	}
 	if s.calls != 1 {
@@ -46,11 +58,11 @@ func TestCollectionsCache(t *testing.T) {
 	}
 
 	// expire cache to force refresh
-	srv.cache.mu.Lock()
-	srv.cache.fetched = time.Now().Add(-2 * cfg.CacheTTL)
-	srv.cache.mu.Unlock()
+	srv.primary.cache.mu.Lock()
+	srv.primary.cache.fetched = time.Now().Add(-2 * cfg.CacheTTL)
+	srv.primary.cache.mu.Unlock()
 
-	if _, err := srv.collections(context.Background()); err != nil {
+	if _, err := srv.collections(context.Background(), srv.primary, false); err != nil {
 		t.Fatalf("collections after expiry: %v", err)
 	}
 	if s.calls != 2 {
@@ -58,6 +70,352 @@ func TestCollectionsCache(t *testing.T) {
 	}
 }
 
+func TestCollectionCachePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	items := []scraper.Collection{
+		{Date: mustDate(t, 2025, 12, 2, 6), Type: "Refuse", Note: "Bank holiday"},
+	}
+
+	if err := newCollectionCache(path, time.Hour).Set(items); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	restored, ok := newCollectionCache(path, time.Hour).Get(time.Hour)
+	if !ok || len(restored) != 1 {
+		t.Fatalf("expected persisted items, got %v (ok=%v)", restored, ok)
+	}
+	if restored[0].Type != "Refuse" || restored[0].Note != "Bank holiday" || !restored[0].Date.Equal(items[0].Date) {
+		t.Fatalf("unexpected restored item: %+v", restored[0])
+	}
+
+	if _, ok := newCollectionCache(path, time.Nanosecond).Stale(); ok {
+		t.Fatalf("expected snapshot older than TTL to be ignored")
+	}
+
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatalf("write corrupt cache: %v", err)
+	}
+	if _, ok := newCollectionCache(path, time.Hour).Stale(); ok {
+		t.Fatalf("expected corrupt snapshot to be treated as a miss")
+	}
+}
+
+func TestNewRejectsUnknownTimezone(t *testing.T) {
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/Atlantis",
+	}
+	srv, err := New(cfg, &fakeScraper{}, &noopCalendar{}, nil)
+	if err == nil {
+		t.Fatalf("expected error for unknown timezone, got server with location %v", srv.location)
+	}
+	if !strings.Contains(err.Error(), "Europe/Atlantis") {
+		t.Fatalf("expected error to name the timezone, got %v", err)
+	}
+}
+
+func TestStaleCacheServedOnScrapeError(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(httptest.NewRecorder(), nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 2, 6), Type: "Refuse"},
+		},
+	}
+	cfg := config.Config{
+		ListenAddr:        ":0",
+		CacheTTL:          time.Hour,
+		Timezone:          "Europe/London",
+		ServeStaleOnError: true,
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+
+	if _, err := srv.collections(context.Background(), srv.primary, false); err != nil {
+		t.Fatalf("collections: %v", err)
+	}
+
+	// expire cache and break the scraper
+	srv.primary.cache.mu.Lock()
+	srv.primary.cache.fetched = time.Now().Add(-2 * cfg.CacheTTL)
+	srv.primary.cache.mu.Unlock()
+	s.err = errors.New("council site down")
+	s.collections = nil
+
+	req := httptest.NewRequest("GET", "/api/next?now=2025-12-01T07:30:00Z", nil)
+	rr := httptest.NewRecorder()
+	srv.nextHandler(rr, req)
+
+	if rr.Code != 200 {
+		t.Fatalf("expected stale data to be served, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("X-Data-Stale"); got != "true" {
+		t.Fatalf("expected X-Data-Stale header, got %q", got)
+	}
+	if s.calls != 2 {
+		t.Fatalf("expected scraper to be retried before falling back, got %d calls", s.calls)
+	}
+
+	srv.cfg.ServeStaleOnError = false
+	rr = httptest.NewRecorder()
+	srv.nextHandler(rr, req)
+	if rr.Code != 503 {
+		t.Fatalf("expected 503 with stale serving disabled, got %d", rr.Code)
+	}
+}
+
+func TestHealthHandlerDegraded(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(httptest.NewRecorder(), nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 2, 6), Type: "Refuse"},
+		},
+	}
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+
+	if _, err := srv.collections(context.Background(), srv.primary, false); err != nil {
+		t.Fatalf("collections: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	srv.healthHandler(rr, httptest.NewRequest("GET", "/healthz", nil))
+	if rr.Code != 200 {
+		t.Fatalf("expected 200 after successful scrape, got %d", rr.Code)
+	}
+
+	s.err = errors.New("council site down")
+	for i := 0; i < 3; i++ {
+		if _, err := srv.collections(context.Background(), srv.primary, true); err == nil {
+			t.Fatalf("expected scrape error")
+		}
+	}
+	srv.healthMu.Lock()
+	srv.lastSuccess = time.Now().Add(-3 * cfg.CacheTTL)
+	srv.healthMu.Unlock()
+
+	rr = httptest.NewRecorder()
+	srv.healthHandler(rr, httptest.NewRequest("GET", "/healthz", nil))
+	if rr.Code != 503 {
+		t.Fatalf("expected 503 when scrapes keep failing, got %d", rr.Code)
+	}
+
+	var payload struct {
+		Status               string  `json:"status"`
+		LastSuccessfulScrape *string `json:"last_successful_scrape"`
+		CacheAgeSeconds      *int    `json:"cache_age_seconds"`
+		LastError            string  `json:"last_error"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if payload.Status != "degraded" || payload.LastError != "council site down" {
+		t.Fatalf("unexpected payload %+v", payload)
+	}
+	if payload.LastSuccessfulScrape == nil || payload.CacheAgeSeconds == nil {
+		t.Fatalf("expected scrape timestamps in payload %+v", payload)
+	}
+}
+
+func TestHealthHandlerStaleWithoutErrors(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 2, 6), Type: "Refuse"},
+		},
+	}
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+
+	srv.healthMu.Lock()
+	srv.startedAt = time.Now().Add(-3 * cfg.CacheTTL)
+	srv.healthMu.Unlock()
+	rr := httptest.NewRecorder()
+	srv.healthHandler(rr, httptest.NewRequest("GET", "/healthz", nil))
+	if rr.Code != 200 {
+		t.Fatalf("expected 200 for an idle instance without a refresh loop, got %d", rr.Code)
+	}
+
+	if _, err := srv.collections(context.Background(), srv.primary, false); err != nil {
+		t.Fatalf("collections: %v", err)
+	}
+	srv.healthMu.Lock()
+	srv.lastSuccess = time.Now().Add(-3 * cfg.CacheTTL)
+	srv.healthMu.Unlock()
+	rr = httptest.NewRecorder()
+	srv.healthHandler(rr, httptest.NewRequest("GET", "/healthz", nil))
+	if rr.Code != 200 {
+		t.Fatalf("expected 200 for a stale success with no refresh loop or errors, got %d", rr.Code)
+	}
+
+	// With a refresh loop, a stale success means the loop has stopped
+	// scraping even though nothing has failed.
+	srv.cfg.RefreshInterval = time.Minute
+	rr = httptest.NewRecorder()
+	srv.healthHandler(rr, httptest.NewRequest("GET", "/healthz", nil))
+	if rr.Code != 503 {
+		t.Fatalf("expected 503 for a stale success with a refresh loop, got %d", rr.Code)
+	}
+	var payload struct {
+		Status    string  `json:"status"`
+		LastError *string `json:"last_error"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if payload.Status != "degraded" || payload.LastError != nil {
+		t.Fatalf("unexpected payload %+v", payload)
+	}
+}
+
+func TestRefreshHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(httptest.NewRecorder(), nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse"},
+			{Date: mustDate(t, 2025, 12, 2, 6), Type: "Recycling"},
+		},
+	}
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+
+	if _, err := srv.collections(context.Background(), srv.primary, false); err != nil {
+		t.Fatalf("collections: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	srv.refreshHandler(rr, httptest.NewRequest("POST", "/api/refresh", nil))
+	if rr.Code != 200 {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if s.calls != 2 {
+		t.Fatalf("expected forced refresh to bypass cache, scraper called %d times", s.calls)
+	}
+
+	var payload struct {
+		Refreshed bool `json:"refreshed"`
+		Items     int  `json:"items"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !payload.Refreshed || payload.Items != 2 {
+		t.Fatalf("unexpected payload %+v", payload)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.refreshHandler(rr, httptest.NewRequest("POST", "/api/refresh", nil))
+	if rr.Code != 429 {
+		t.Fatalf("expected 429 for rapid refresh, got %d", rr.Code)
+	}
+	if s.calls != 2 {
+		t.Fatalf("rate-limited refresh should not scrape, scraper called %d times", s.calls)
+	}
+}
+
+func TestCalendarHandlerPerUPRN(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(httptest.NewRecorder(), nil))
+	home := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse"},
+		},
+	}
+	rental := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 3, 6), Type: "Recycling"},
+		},
+	}
+	cal, _ := calendar.NewBuilder(calendar.Config{
+		Name:     "Redbridge Collections",
+		Timezone: "Europe/London",
+	})
+	cfg := config.Config{
+		ListenAddr: ":0",
+		UPRN:       "111",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, home, cal, logger)
+	srv.AddAddress("222", rental)
+
+	rr := httptest.NewRecorder()
+	srv.calendarHandler(rr, httptest.NewRequest("GET", "/calendar.ics", nil))
+	if !strings.Contains(rr.Body.String(), "UID:refuse-20251201@redbridge-ics") {
+		t.Fatalf("expected default UPRN feed, got %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	srv.calendarHandler(rr, httptest.NewRequest("GET", "/calendar.ics?uprn=222", nil))
+	body := rr.Body.String()
+	if !strings.Contains(body, "UID:recycling-20251203@redbridge-ics") || strings.Contains(body, "UID:refuse-20251201@redbridge-ics") {
+		t.Fatalf("expected rental feed only, got %s", body)
+	}
+	if home.calls != 1 || rental.calls != 1 {
+		t.Fatalf("expected one scrape per address, got home=%d rental=%d", home.calls, rental.calls)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.calendarHandler(rr, httptest.NewRequest("GET", "/calendar.ics?uprn=999", nil))
+	if rr.Code != 404 {
+		t.Fatalf("expected 404 for unknown UPRN, got %d", rr.Code)
+	}
+}
+
+func TestCalendarLocationPerUPRN(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	home := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse"},
+		},
+	}
+	rental := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 3, 6), Type: "Recycling"},
+		},
+	}
+	cal, _ := calendar.NewBuilder(calendar.Config{
+		Name:     "Redbridge Collections",
+		Timezone: "Europe/London",
+		Location: "1 High Road, IG1 1AA",
+	})
+	cfg := config.Config{
+		ListenAddr:     ":0",
+		UPRN:           "111",
+		CacheTTL:       time.Hour,
+		Timezone:       "Europe/London",
+		UIDIncludeUPRN: true,
+	}
+	srv := mustNew(t, cfg, home, cal, logger)
+	srv.AddAddress("222", rental)
+
+	rr := httptest.NewRecorder()
+	srv.calendarHandler(rr, httptest.NewRequest("GET", "/calendar.ics", nil))
+	if !strings.Contains(rr.Body.String(), "LOCATION:1 High Road\\, IG1 1AA") {
+		t.Fatalf("expected the primary address as LOCATION, got %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	srv.calendarHandler(rr, httptest.NewRequest("GET", "/calendar.ics?uprn=222", nil))
+	body := rr.Body.String()
+	if strings.Contains(body, "LOCATION") {
+		t.Fatalf("expected no LOCATION for a second address, got %s", body)
+	}
+	if !strings.Contains(body, "UID:recycling-20251203-222@redbridge-ics") {
+		t.Fatalf("expected UIDs scoped to the second address, got %s", body)
+	}
+}
+
 func TestNextHandler(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(httptest.NewRecorder(), nil))
 
@@ -75,7 +433,7 @@ func TestNextHandler(t *testing.T) {
 		Timezone:   "Europe/London",
 	}
 
-	srv := New(cfg, s, cals, logger)
+	srv := mustNew(t, cfg, s, cals, logger)
 
 	req := httptest.NewRequest("GET", "/api/next?now=2025-12-01T07:30:00Z", nil)
 	rr := httptest.NewRecorder()
@@ -105,76 +463,3011 @@ func TestNextHandler(t *testing.T) {
 	}
 }
 
-func TestCalendarHandler(t *testing.T) {
-	logger := slog.New(slog.NewTextHandler(httptest.NewRecorder(), nil))
+func TestNextTypeHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 	s := &fakeScraper{
 		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 11, 20, 6), Type: "Garden Waste"},
 			{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse"},
+			{Date: mustDate(t, 2025, 12, 2, 6), Type: "Recycling"},
+			{Date: mustDate(t, 2025, 12, 8, 6), Type: "Refuse"},
 		},
 	}
-	cal := &fakeCalendarBuilder{ics: []byte("BEGIN:VCALENDAR\nEND:VCALENDAR")}
 	cfg := config.Config{
 		ListenAddr: ":0",
 		CacheTTL:   time.Hour,
 		Timezone:   "Europe/London",
 	}
-	srv := New(cfg, s, cal, logger)
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
 
-	req := httptest.NewRequest("GET", "/calendar.ics", nil)
 	rr := httptest.NewRecorder()
-	srv.calendarHandler(rr, req)
-
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/next/refuse?now=2025-12-01T09:00:00Z", nil))
 	if rr.Code != 200 {
-		t.Fatalf("expected 200, got %d", rr.Code)
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
 	}
-	if got := rr.Header().Get("Content-Type"); got != "text/calendar; charset=utf-8" {
-		t.Fatalf("unexpected content-type %s", got)
+	var payload struct {
+		Date string `json:"date"`
+		Days int    `json:"days"`
 	}
-	if body := rr.Body.String(); !strings.Contains(body, "VCALENDAR") {
-		t.Fatalf("unexpected body %s", body)
+	if err := json.Unmarshal(rr.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if payload.Date != "2025-12-08" || payload.Days != 7 {
+		t.Fatalf("unexpected payload %+v", payload)
+	}
+
+	// Garden waste is suspended for the winter: no collection after "now".
+	rr = httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/next/garden-waste?now=2025-12-01T09:00:00Z", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for suspended garden waste, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "no_upcoming_collections") {
+		t.Fatalf("unexpected body %s", rr.Body.String())
 	}
 }
 
-func TestMetricsHandler(t *testing.T) {
-	logger := slog.New(slog.NewTextHandler(httptest.NewRecorder(), nil))
-	s := &fakeScraper{collections: []scraper.Collection{}}
-	cal := &fakeCalendarBuilder{}
+func TestTodayWindow(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse"},
+			{Date: mustDate(t, 2025, 12, 8, 6), Type: "Refuse"},
+		},
+	}
 	cfg := config.Config{
-		ListenAddr: ":0",
-		CacheTTL:   time.Hour,
-		Timezone:   "Europe/London",
+		ListenAddr:  ":0",
+		CacheTTL:    time.Hour,
+		Timezone:    "Europe/London",
+		TodayWindow: 3 * time.Hour,
 	}
-	srv := New(cfg, s, cal, logger)
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
 
-	req := httptest.NewRequest("GET", "/metrics", nil)
-	rr := httptest.NewRecorder()
-	srv.metrics.handler().ServeHTTP(rr, req)
+	cases := []struct {
+		now   string
+		today bool
+		next  string
+	}{
+		{"2025-12-01T07:30:00Z", true, "2025-12-01"},
+		{"2025-12-01T08:59:59Z", true, "2025-12-01"},
+		{"2025-12-01T09:00:00Z", false, "2025-12-01"},
+		{"2025-12-01T09:00:01Z", false, "2025-12-08"},
+	}
+	for _, tc := range cases {
+		rr := httptest.NewRecorder()
+		srv.isTodayHandler(rr, httptest.NewRequest("GET", "/api/is-today?now="+tc.now, nil))
+		var today struct {
+			Today bool `json:"today"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &today); err != nil {
+			t.Fatalf("%s: unmarshal: %v", tc.now, err)
+		}
+		if today.Today != tc.today {
+			t.Fatalf("%s: today = %v, want %v", tc.now, today.Today, tc.today)
+		}
 
-	if rr.Code != 200 {
-		t.Fatalf("expected 200, got %d", rr.Code)
+		rr = httptest.NewRecorder()
+		srv.nextHandler(rr, httptest.NewRequest("GET", "/api/next?now="+tc.now, nil))
+		var next struct {
+			Date string `json:"date"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &next); err != nil {
+			t.Fatalf("%s: unmarshal: %v", tc.now, err)
+		}
+		if next.Date != tc.next {
+			t.Fatalf("%s: next = %s, want %s", tc.now, next.Date, tc.next)
+		}
 	}
-	if !strings.Contains(rr.Body.String(), "redbridge_cache_hits_total") {
-		t.Fatalf("expected metrics output, got %s", rr.Body.String())
+
+	// COLLECTION_WINDOW_END keeps it today's until the window closes.
+	cfg.TodayWindow = time.Hour
+	cfg.WindowEndHour = 16
+	srv = mustNew(t, cfg, s, &noopCalendar{}, logger)
+	for _, tc := range []struct {
+		now   string
+		today bool
+		next  string
+	}{
+		{"2025-12-01T12:00:00Z", true, "2025-12-01"},
+		{"2025-12-01T15:59:59Z", true, "2025-12-01"},
+		{"2025-12-01T16:00:00Z", false, "2025-12-01"},
+		{"2025-12-01T16:00:01Z", false, "2025-12-08"},
+	} {
+		rr := httptest.NewRecorder()
+		srv.isTodayHandler(rr, httptest.NewRequest("GET", "/api/is-today?now="+tc.now, nil))
+		var today struct {
+			Today bool `json:"today"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &today); err != nil {
+			t.Fatalf("%s: unmarshal: %v", tc.now, err)
+		}
+		if today.Today != tc.today {
+			t.Fatalf("%s: today = %v, want %v", tc.now, today.Today, tc.today)
+		}
+		rr = httptest.NewRecorder()
+		srv.nextHandler(rr, httptest.NewRequest("GET", "/api/next?now="+tc.now, nil))
+		if want := `"date":"` + tc.next + `"`; !strings.Contains(rr.Body.String(), want) {
+			t.Fatalf("%s: expected next %s, got %s", tc.now, tc.next, rr.Body.String())
+		}
 	}
-}
 
-type fakeScraper struct {
-	collections []scraper.Collection
-	err         error
-	calls       int
+	// Without TODAY_WINDOW the default one-hour window applies.
+	srv = mustNew(t, config.Config{ListenAddr: ":0", CacheTTL: time.Hour, Timezone: "Europe/London"}, s, &noopCalendar{}, logger)
+	rr := httptest.NewRecorder()
+	srv.isTodayHandler(rr, httptest.NewRequest("GET", "/api/is-today?now=2025-12-01T07:30:00Z", nil))
+	if !strings.Contains(rr.Body.String(), `"today":false`) {
+		t.Fatalf("expected default window to have closed by 07:30, got %s", rr.Body.String())
+	}
 }
 
-func (f *fakeScraper) FetchCollections(ctx context.Context) ([]scraper.Collection, error) {
-	f.calls++
-	return f.collections, f.err
-}
+func TestTimezoneOverride(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			// 06:00 in London is 20:00 the evening before in Honolulu.
+			{Date: mustDate(t, 2025, 12, 2, 6), Type: "Refuse"},
+		},
+	}
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
 
-type fakeCalendarBuilder struct {
-	ics []byte
-	err error
-}
+	get := func(path string) map[string]interface{} {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		if rr.Code != 200 {
+			t.Fatalf("%s: expected 200, got %d", path, rr.Code)
+		}
+		var payload map[string]interface{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &payload); err != nil {
+			t.Fatalf("%s: unmarshal: %v", path, err)
+		}
+		return payload
+	}
+
+	const now = "now=2025-12-01T10:00:00-10:00"
+	london := get("/api/next?" + now)
+	if london["date"] != "2025-12-02" || london["days"] != float64(1) {
+		t.Fatalf("unexpected London next %v", london)
+	}
+	honolulu := get("/api/next?tz=Pacific/Honolulu&" + now)
+	if honolulu["date"] != "2025-12-01" || honolulu["days"] != float64(0) {
+		t.Fatalf("unexpected Honolulu next %v", honolulu)
+	}
+	if got := get("/api/is-today?tz=Pacific/Honolulu&" + now); got["today"] != true {
+		t.Fatalf("expected a collection today in Honolulu, got %v", got)
+	}
+	if got := get("/api/is-tomorrow?tz=Pacific/Honolulu&" + now); got["tomorrow"] != false {
+		t.Fatalf("expected no collection tomorrow in Honolulu, got %v", got)
+	}
+	if got := get("/api/is-tomorrow?" + now); got["tomorrow"] != true {
+		t.Fatalf("expected a collection tomorrow in London, got %v", got)
+	}
+	types := get("/api/types?tz=Pacific/Honolulu&" + now)
+	if today, _ := types["today"].([]interface{}); len(today) != 1 || today[0] != "Refuse" {
+		t.Fatalf("unexpected Honolulu types %v", types)
+	}
+	if tomorrow, _ := types["tomorrow"].([]interface{}); len(tomorrow) != 0 {
+		t.Fatalf("unexpected Honolulu types %v", types)
+	}
+
+	// A date-only now is midnight in the requested zone.
+	if got := get("/api/next?tz=Pacific/Honolulu&now=2025-12-01"); got["days"] != float64(0) {
+		t.Fatalf("expected the collection today for a date-only now, got %v", got)
+	}
+
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/next?tz=Mars/Olympus", nil))
+	if rr.Code != 400 || !strings.Contains(rr.Body.String(), "invalid_tz") {
+		t.Fatalf("expected 400 invalid_tz, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestCountdownHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse"},
+			{Date: mustDate(t, 2025, 12, 2, 6), Type: "Recycling"},
+			{Date: mustDate(t, 2025, 12, 2, 6), Type: "Food Waste"},
+		},
+	}
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+
+	cases := []struct {
+		now     string
+		seconds int64
+		date    string
+	}{
+		// 20:30 the evening before: 9h30m to go.
+		{"2025-11-30T20:30:00Z", 9*3600 + 30*60, "2025-12-01"},
+		// Within the collection window the countdown clamps at zero.
+		{"2025-12-01T06:30:00Z", 0, "2025-12-01"},
+		// Once the window closes, the next day is counted down to.
+		{"2025-12-01T07:00:01Z", 23*3600 - 1, "2025-12-02"},
+	}
+	for _, tc := range cases {
+		rr := httptest.NewRecorder()
+		srv.countdownHandler(rr, httptest.NewRequest("GET", "/api/countdown?now="+tc.now, nil))
+		if rr.Code != 200 {
+			t.Fatalf("%s: expected 200, got %d", tc.now, rr.Code)
+		}
+		var payload struct {
+			Seconds int64    `json:"seconds"`
+			Date    string   `json:"date"`
+			Types   []string `json:"types"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &payload); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if payload.Seconds != tc.seconds || payload.Date != tc.date {
+			t.Fatalf("%s: got %+v, want %d seconds to %s", tc.now, payload, tc.seconds, tc.date)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	srv.countdownHandler(rr, httptest.NewRequest("GET", "/api/countdown?now=2025-12-03T00:00:00Z", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 with nothing upcoming, got %d", rr.Code)
+	}
+}
+
+func TestTypesHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse"},
+			{Date: mustDate(t, 2025, 12, 2, 6), Type: "Recycling"},
+			{Date: mustDate(t, 2025, 12, 2, 6), Type: "Food Waste"},
+			{Date: mustDate(t, 2025, 12, 8, 6), Type: "Refuse" + scraper.NoCollectionSuffix},
+			{Date: mustDate(t, 2025, 12, 14, 6), Type: "Garden Waste"},
+		},
+	}
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+
+	rr := httptest.NewRecorder()
+	srv.typesHandler(rr, httptest.NewRequest("GET", "/api/types?now=2025-12-01T05:00:00Z", nil))
+	if rr.Code != 200 {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var payload struct {
+		Today    []string `json:"today"`
+		Tomorrow []string `json:"tomorrow"`
+		All      []string `json:"all"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if strings.Join(payload.Today, ",") != "Refuse" || strings.Join(payload.Tomorrow, ",") != "Recycling,Food Waste" {
+		t.Fatalf("unexpected today/tomorrow %+v", payload)
+	}
+	if got, want := strings.Join(payload.All, ","), "Food Waste,Garden Waste,Recycling,Refuse"; got != want {
+		t.Fatalf("all = %s, want %s", got, want)
+	}
+}
+
+func TestNextHandlerDateOnlyNow(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(httptest.NewRecorder(), nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 3, 6), Type: "Refuse"},
+		},
+	}
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+
+	rr := httptest.NewRecorder()
+	srv.nextHandler(rr, httptest.NewRequest("GET", "/api/next?now=2025-12-01", nil))
+	if rr.Code != 200 {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+
+	var payload struct {
+		Date string `json:"date"`
+		Days int    `json:"days"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if payload.Date != "2025-12-03" || payload.Days != 2 {
+		t.Fatalf("unexpected payload %+v", payload)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.nextHandler(rr, httptest.NewRequest("GET", "/api/next?now=01-12-2025", nil))
+	if rr.Code != 400 {
+		t.Fatalf("expected 400 for invalid now, got %d", rr.Code)
+	}
+}
+
+func TestNextHandlerIncludeToday(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse"},
+			{Date: mustDate(t, 2025, 12, 8, 6), Type: "Recycling"},
+		},
+	}
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+
+	cases := []struct {
+		query string
+		date  string
+	}{
+		// Just after the 06:00 start, today's collection is still next.
+		{"now=2025-12-01T06:05:00Z", "2025-12-01"},
+		{"now=2025-12-01T06:05:00Z&include_today=true", "2025-12-01"},
+		{"now=2025-12-01T06:05:00Z&include_today=false", "2025-12-08"},
+		// Once the collection window has closed, today is skipped either way.
+		{"now=2025-12-01T07:05:00Z", "2025-12-08"},
+		{"now=2025-12-01T07:05:00Z&include_today=false", "2025-12-08"},
+	}
+	for _, tc := range cases {
+		rr := httptest.NewRecorder()
+		srv.nextHandler(rr, httptest.NewRequest("GET", "/api/next?"+tc.query, nil))
+		if rr.Code != 200 {
+			t.Fatalf("%s: expected 200, got %d", tc.query, rr.Code)
+		}
+		var payload struct {
+			Date string `json:"date"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &payload); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if payload.Date != tc.date {
+			t.Fatalf("%s: expected %s, got %s", tc.query, tc.date, payload.Date)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	srv.nextHandler(rr, httptest.NewRequest("GET", "/api/next?include_today=maybe", nil))
+	if rr.Code != 400 {
+		t.Fatalf("expected 400 for invalid include_today, got %d", rr.Code)
+	}
+}
+
+func TestLandingHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{}
+	cfg := config.Config{
+		ListenAddr:   ":0",
+		CacheTTL:     time.Hour,
+		Timezone:     "Europe/London",
+		CalendarName: "Redbridge Collections",
+		AuthToken:    "secret",
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "http://bins.example.com/?token=secret", nil))
+	if rr.Code != 200 {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	body := rr.Body.String()
+	for _, want := range []string{"Redbridge Collections", "http://bins.example.com/calendar.ics?token=secret", "webcal://bins.example.com/calendar.ics?token=secret", "/api/next?token=secret"} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected %q in landing page, got %s", want, body)
+		}
+	}
+	if s.calls != 0 {
+		t.Fatalf("expected no scrape, got %d", s.calls)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/unknown?token=secret", nil))
+	if rr.Code != 404 {
+		t.Fatalf("expected 404 for an unknown path, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/favicon.ico", nil))
+	if rr.Code != 200 || rr.Header().Get("Content-Type") != "image/x-icon" || rr.Body.Len() == 0 {
+		t.Fatalf("expected the favicon without a token, got %d %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{}
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/openapi.json", nil))
+	if rr.Code != 200 {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	if s.calls != 0 {
+		t.Fatalf("expected no scrape, got %d", s.calls)
+	}
+
+	var doc struct {
+		OpenAPI string                    `json:"openapi"`
+		Paths   map[string]map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Fatalf("unexpected openapi version %q", doc.OpenAPI)
+	}
+	routes := map[string]string{
+		"/":                "get",
+		"/favicon.ico":     "get",
+		"/calendar.ics":    "get",
+		"/calendar/{file}": "get",
+		"/calendar.csv":    "get",
+		"/calendar.webcal": "get",
+		"/freebusy.ifb":    "get",
+		"/api/next":        "get",
+		"/api/next/{type}": "get",
+		"/api/countdown":   "get",
+		"/api/week":        "get",
+		"/api/schedule":    "get",
+		"/api/agenda":      "get",
+		"/api/types":       "get",
+		"/api/is-today":    "get",
+		"/api/is-tomorrow": "get",
+		"/api/feeds":       "get",
+		"/api/stream":      "get",
+		"/api/events":      "get",
+		"/api/addresses":   "get",
+		"/api/refresh":     "post",
+		"/healthz":         "get",
+		"/metrics":         "get",
+		"/openapi.json":    "get",
+	}
+	for path, method := range routes {
+		if _, ok := doc.Paths[path][method]; !ok {
+			t.Errorf("expected %s %s in the document", method, path)
+		}
+	}
+}
+
+func TestWeekHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(httptest.NewRecorder(), nil))
+
+	cals := &noopCalendar{}
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse"},
+			{Date: mustDate(t, 2025, 12, 2, 6), Type: "Recycling"},
+			{Date: mustDate(t, 2025, 12, 8, 6), Type: "Refuse"},
+			{Date: mustDate(t, 2025, 12, 8, 6), Type: "Food Waste"},
+			{Date: mustDate(t, 2025, 12, 9, 6), Type: "Recycling"},
+			{Date: mustDate(t, 2025, 12, 4, 6), Type: "Garden Waste" + scraper.NoCollectionSuffix},
+		},
+	}
+
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+
+	srv := mustNew(t, cfg, s, cals, logger)
+
+	req := httptest.NewRequest("GET", "/api/week?now=2025-12-01T07:30:00Z", nil)
+	rr := httptest.NewRecorder()
+	srv.weekHandler(rr, req)
+
+	if rr.Code != 200 {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+
+	var payload []struct {
+		Date  string   `json:"date"`
+		Days  int      `json:"days"`
+		Types []string `json:"types"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	if len(payload) != 2 {
+		t.Fatalf("expected 2 days within the week, got %d", len(payload))
+	}
+	if payload[0].Date != "2025-12-02" || payload[0].Days != 1 {
+		t.Fatalf("unexpected first day %+v", payload[0])
+	}
+	if payload[1].Date != "2025-12-08" || payload[1].Days != 7 {
+		t.Fatalf("unexpected last day %+v", payload[1])
+	}
+	if len(payload[1].Types) != 2 {
+		t.Fatalf("expected 2 types on boundary day, got %v", payload[1].Types)
+	}
+
+	req = httptest.NewRequest("GET", "/api/week?now=2026-01-01T07:30:00Z", nil)
+	rr = httptest.NewRecorder()
+	srv.weekHandler(rr, req)
+
+	if rr.Code != 200 {
+		t.Fatalf("expected 200 when nothing scheduled, got %d", rr.Code)
+	}
+	if body := strings.TrimSpace(rr.Body.String()); body != "[]" {
+		t.Fatalf("expected empty array, got %s", body)
+	}
+}
+
+func TestAgendaHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 9, 6), Type: "Refuse"},
+			{Date: mustDate(t, 2025, 11, 25, 6), Type: "Refuse"},
+			{Date: mustDate(t, 2025, 12, 2, 6), Type: "Refuse"},
+			{Date: mustDate(t, 2025, 12, 2, 6), Type: "Recycling"},
+			{Date: mustDate(t, 2025, 12, 3, 6), Type: "Garden Waste"},
+		},
+	}
+	cfg := config.Config{
+		ListenAddr:   ":0",
+		CacheTTL:     time.Hour,
+		Timezone:     "Europe/London",
+		CalendarName: "Redbridge Collections",
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/agenda?now=2025-12-02T05:00:00Z", nil))
+	if got := rr.Header().Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Fatalf("unexpected content type %s", got)
+	}
+	want := "Tue 02 Dec — Refuse, Recycling (today)\n" +
+		"Wed 03 Dec — Garden Waste (tomorrow)\n" +
+		"Tue 09 Dec — Refuse\n"
+	if got := rr.Body.String(); got != want {
+		t.Fatalf("unexpected agenda:\n%s", got)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/agenda?now=2025-12-02T05:00:00Z&format=html", nil))
+	body := rr.Body.String()
+	if !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("unexpected html content type %s", rr.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(body, `<span class="date">Tue 02 Dec</span>Refuse, Recycling<span class="marker">today</span>`) {
+		t.Fatalf("expected today marker in html agenda, got %s", body)
+	}
+	if strings.Index(body, "Tue 02 Dec") > strings.Index(body, "Tue 09 Dec") {
+		t.Fatalf("expected html agenda in date order")
+	}
+}
+
+func TestJSONCacheControl(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: time.Now().AddDate(0, 0, 3), Type: "Refuse"},
+		},
+	}
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+
+	for _, path := range []string{"/api/next", "/api/week", "/api/schedule", "/api/types", "/api/is-today", "/api/is-tomorrow"} {
+		rr := httptest.NewRecorder()
+		srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		if got := rr.Header().Get("Cache-Control"); got != cacheControlJSON {
+			t.Fatalf("%s: expected %q, got %q", path, cacheControlJSON, got)
+		}
+
+		rr = httptest.NewRecorder()
+		srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", path+"?now=2025-12-01", nil))
+		if got := rr.Header().Get("Cache-Control"); got != "no-store" {
+			t.Fatalf("%s?now: expected no-store, got %q", path, got)
+		}
+	}
+}
+
+func TestScheduleHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(httptest.NewRecorder(), nil))
+
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 9, 6), Type: "Refuse", Frequency: "weekly"},
+			{Date: mustDate(t, 2025, 12, 2, 6), Type: "Recycling", Note: "Date changed due to bank holiday.", Frequency: "unknown"},
+			{Date: mustDate(t, 2025, 12, 2, 6), Type: "Refuse", Note: "Date changed due to bank holiday.", Frequency: "weekly"},
+		},
+	}
+
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+
+	req := httptest.NewRequest("GET", "/api/schedule?now=2025-12-01T07:30:00Z", nil)
+	rr := httptest.NewRecorder()
+	srv.scheduleHandler(rr, req)
+
+	if rr.Code != 200 {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Cache-Control"); got != "no-store" {
+		t.Fatalf("unexpected cache-control %s", got)
+	}
+
+	var payload []struct {
+		Date        string            `json:"date"`
+		Types       []string          `json:"types"`
+		Frequencies map[string]string `json:"frequencies"`
+		Note        string            `json:"note"`
+		DaysUntil   int               `json:"days_until"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	if len(payload) != 2 {
+		t.Fatalf("expected 2 days, got %d", len(payload))
+	}
+	if payload[0].Date != "2025-12-02" || payload[1].Date != "2025-12-09" {
+		t.Fatalf("unexpected ordering %s, %s", payload[0].Date, payload[1].Date)
+	}
+	if len(payload[0].Types) != 2 {
+		t.Fatalf("expected 2 types on first day, got %v", payload[0].Types)
+	}
+	if payload[0].Note != "Date changed due to bank holiday." {
+		t.Fatalf("unexpected note %q", payload[0].Note)
+	}
+	if payload[0].Frequencies["Refuse"] != "weekly" || payload[0].Frequencies["Recycling"] != "unknown" {
+		t.Fatalf("unexpected frequencies %v", payload[0].Frequencies)
+	}
+	if payload[1].Note != "" {
+		t.Fatalf("expected empty note, got %q", payload[1].Note)
+	}
+	if payload[0].DaysUntil != 1 || payload[1].DaysUntil != 8 {
+		t.Fatalf("unexpected days_until %d, %d", payload[0].DaysUntil, payload[1].DaysUntil)
+	}
+}
+
+func TestScheduleHandlerPagination(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	var collections []scraper.Collection
+	for day := 2; day <= 30; day += 7 {
+		collections = append(collections, scraper.Collection{Date: mustDate(t, 2025, 12, day, 6), Type: "Refuse"})
+	}
+	cfg := config.Config{ListenAddr: ":0", CacheTTL: time.Hour, Timezone: "Europe/London"}
+	srv := mustNew(t, cfg, &fakeScraper{collections: collections}, &noopCalendar{}, logger)
+
+	type page struct {
+		Days []struct {
+			Date string `json:"date"`
+		} `json:"days"`
+		Total      int  `json:"total"`
+		NextOffset *int `json:"next_offset"`
+	}
+	get := func(query string) page {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/api/schedule?now=2025-12-01&"+query, nil)
+		rr := httptest.NewRecorder()
+		srv.httpServer.Handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", query, rr.Code, rr.Body.String())
+		}
+		var p page
+		if err := json.Unmarshal(rr.Body.Bytes(), &p); err != nil {
+			t.Fatalf("%s: unmarshal: %v", query, err)
+		}
+		return p
+	}
+
+	first := get("limit=2")
+	if first.Total != 5 || len(first.Days) != 2 || first.Days[0].Date != "2025-12-02" || first.Days[1].Date != "2025-12-09" {
+		t.Fatalf("unexpected first page %+v", first)
+	}
+	if first.NextOffset == nil || *first.NextOffset != 2 {
+		t.Fatalf("expected next_offset 2, got %v", first.NextOffset)
+	}
+
+	second := get("limit=2&offset=2")
+	if len(second.Days) != 2 || second.Days[0].Date != "2025-12-16" || second.NextOffset == nil || *second.NextOffset != 4 {
+		t.Fatalf("unexpected second page %+v", second)
+	}
+
+	last := get("limit=2&offset=4")
+	if len(last.Days) != 1 || last.Days[0].Date != "2025-12-30" || last.NextOffset != nil {
+		t.Fatalf("unexpected last page %+v", last)
+	}
+
+	rest := get("offset=3")
+	if len(rest.Days) != 2 || rest.Total != 5 || rest.NextOffset != nil {
+		t.Fatalf("unexpected offset-only page %+v", rest)
+	}
+
+	past := get("offset=9&limit=2")
+	if len(past.Days) != 0 || past.NextOffset != nil {
+		t.Fatalf("expected empty page past the end, got %+v", past)
+	}
+
+	for _, query := range []string{"limit=-1", "offset=x"} {
+		req := httptest.NewRequest("GET", "/api/schedule?"+query, nil)
+		rr := httptest.NewRecorder()
+		srv.httpServer.Handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", query, rr.Code)
+		}
+	}
+}
+
+type validatingScraper struct {
+	fakeScraper
+	validateErr error
+	validations int
+}
+
+func (v *validatingScraper) ValidateAddress(context.Context) error {
+	v.validations++
+	return v.validateErr
+}
+
+func TestValidateHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := config.Config{ListenAddr: ":0", UPRN: "123", CacheTTL: time.Hour, Timezone: "Europe/London"}
+	scr := &validatingScraper{}
+	srv := mustNew(t, cfg, scr, &noopCalendar{}, logger)
+
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/validate", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var payload struct {
+		OK   bool   `json:"ok"`
+		UPRN string `json:"uprn"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !payload.OK || payload.UPRN != "123" {
+		t.Fatalf("unexpected payload %+v", payload)
+	}
+	if scr.validations != 1 || scr.calls != 0 {
+		t.Fatalf("expected only the handshake, got %d validations and %d scrapes", scr.validations, scr.calls)
+	}
+	if got := rr.Header().Get("Cache-Control"); got != "no-store" {
+		t.Fatalf("Cache-Control = %q, want no-store", got)
+	}
+
+	scr.validateErr = scraper.ErrAddressSetup
+	rr = httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/validate", nil))
+	if rr.Code != http.StatusBadGateway || !strings.Contains(rr.Body.String(), "address_setup_failed") {
+		t.Fatalf("expected 502 address_setup_failed, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	srv = mustNew(t, cfg, &fakeScraper{}, &noopCalendar{}, logger)
+	rr = httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/validate", nil))
+	if rr.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501 without validation support, got %d", rr.Code)
+	}
+}
+
+type gardenScraper struct {
+	fakeScraper
+	status scraper.GardenStatus
+}
+
+func (g *gardenScraper) GardenStatus() scraper.GardenStatus {
+	return g.status
+}
+
+func TestScheduleGardenStatus(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	collections := []scraper.Collection{{Date: mustDate(t, 2025, 12, 2, 6), Type: "Refuse"}}
+	cfg := config.Config{ListenAddr: ":0", CacheTTL: time.Hour, Timezone: "Europe/London"}
+
+	srv := mustNew(t, cfg, &gardenScraper{fakeScraper: fakeScraper{collections: collections}, status: scraper.GardenSuspended}, &noopCalendar{}, logger)
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/schedule?now=2025-12-01&limit=1", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("X-Garden-Status"); got != "suspended" {
+		t.Fatalf("X-Garden-Status = %q, want suspended", got)
+	}
+	var page struct {
+		GardenStatus string `json:"garden_status"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &page); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if page.GardenStatus != "suspended" {
+		t.Fatalf("garden_status = %q, want suspended", page.GardenStatus)
+	}
+
+	// Scrapers that can't tell report unknown.
+	srv = mustNew(t, cfg, &fakeScraper{collections: collections}, &noopCalendar{}, logger)
+	rr = httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/schedule?now=2025-12-01", nil))
+	if got := rr.Header().Get("X-Garden-Status"); got != "unknown" {
+		t.Fatalf("X-Garden-Status = %q, want unknown", got)
+	}
+}
+
+func TestSkippedCollections(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 30, 6), Type: "Refuse" + scraper.NoCollectionSuffix, Note: "No collection due to bank holiday."},
+			{Date: mustDate(t, 2026, 1, 6, 6), Type: "Refuse"},
+		},
+	}
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+
+	rr := httptest.NewRecorder()
+	srv.scheduleHandler(rr, httptest.NewRequest("GET", "/api/schedule?now=2025-12-29", nil))
+	var schedule []struct {
+		Date  string   `json:"date"`
+		Types []string `json:"types"`
+		Note  string   `json:"note"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &schedule); err != nil {
+		t.Fatalf("unmarshal schedule: %v", err)
+	}
+	if len(schedule) != 2 || schedule[0].Types[0] != "Refuse (No Collection)" || schedule[0].Note == "" {
+		t.Fatalf("expected skipped week in schedule, got %+v", schedule)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.nextHandler(rr, httptest.NewRequest("GET", "/api/next?now=2025-12-29", nil))
+	var next struct {
+		Date string `json:"date"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &next); err != nil {
+		t.Fatalf("unmarshal next: %v", err)
+	}
+	if next.Date != "2026-01-06" {
+		t.Fatalf("expected next to skip the cancelled week, got %s", next.Date)
+	}
+}
+
+func TestCalendarHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(httptest.NewRecorder(), nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse"},
+		},
+	}
+	cal := &fakeCalendarBuilder{ics: []byte("BEGIN:VCALENDAR\nEND:VCALENDAR")}
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, cal, logger)
+
+	req := httptest.NewRequest("GET", "/calendar.ics", nil)
+	rr := httptest.NewRecorder()
+	srv.calendarHandler(rr, req)
+
+	if rr.Code != 200 {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Content-Type"); got != "text/calendar; charset=utf-8" {
+		t.Fatalf("unexpected content-type %s", got)
+	}
+	if body := rr.Body.String(); !strings.Contains(body, "VCALENDAR") {
+		t.Fatalf("unexpected body %s", body)
+	}
+}
+
+func TestCalendarHandlerTypesFilter(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(httptest.NewRecorder(), nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse"},
+			{Date: mustDate(t, 2025, 12, 2, 6), Type: "Recycling"},
+			{Date: mustDate(t, 2025, 12, 3, 6), Type: "Garden Waste"},
+		},
+	}
+	cal, _ := calendar.NewBuilder(calendar.Config{
+		Name:     "Redbridge Collections",
+		Timezone: "Europe/London",
+	})
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, cal, logger)
+
+	req := httptest.NewRequest("GET", "/calendar.ics?types=refuse,Recycling,Unknown", nil)
+	rr := httptest.NewRecorder()
+	srv.calendarHandler(rr, req)
+
+	if rr.Code != 200 {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Cache-Control"); got != cacheControlICS {
+		t.Fatalf("unexpected cache-control %s", got)
+	}
+	body := rr.Body.String()
+	if !strings.Contains(body, "UID:refuse-20251201@redbridge-ics") {
+		t.Fatalf("expected refuse event in filtered feed")
+	}
+	if !strings.Contains(body, "UID:recycling-20251202@redbridge-ics") {
+		t.Fatalf("expected recycling event in filtered feed")
+	}
+	if strings.Contains(body, "UID:garden-waste-20251203@redbridge-ics") {
+		t.Fatalf("expected garden waste event to be filtered out")
+	}
+}
+
+func TestCalendarHandlerHead(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse"},
+		},
+	}
+	cal, _ := calendar.NewBuilder(calendar.Config{
+		Name:     "Redbridge Collections",
+		Timezone: "Europe/London",
+	})
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, cal, logger)
+	ts := httptest.NewServer(srv.httpServer.Handler)
+	defer ts.Close()
+
+	get, err := http.Get(ts.URL + "/calendar.ics")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	body, _ := io.ReadAll(get.Body)
+	get.Body.Close()
+
+	head, err := http.Head(ts.URL + "/calendar.ics")
+	if err != nil {
+		t.Fatalf("HEAD: %v", err)
+	}
+	defer head.Body.Close()
+	if head.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", head.StatusCode)
+	}
+	if head.ContentLength != int64(len(body)) {
+		t.Fatalf("expected Content-Length %d, got %d", len(body), head.ContentLength)
+	}
+	for _, key := range []string{"Content-Type", "ETag", "Cache-Control", "Last-Modified"} {
+		if got, want := head.Header.Get(key), get.Header.Get(key); got == "" || got != want {
+			t.Fatalf("%s: HEAD %q, GET %q", key, got, want)
+		}
+	}
+	if rest, _ := io.ReadAll(head.Body); len(rest) != 0 {
+		t.Fatalf("expected empty HEAD body, got %d bytes", len(rest))
+	}
+
+	req, _ := http.NewRequest(http.MethodHead, ts.URL+"/calendar.ics", nil)
+	req.Header.Set("If-None-Match", get.Header.Get("ETag"))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("conditional HEAD: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotModified {
+		t.Fatalf("expected 304 for matching ETag, got %d", resp.StatusCode)
+	}
+}
+
+func TestCalendarHandlerDownload(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse"},
+		},
+	}
+	cal, _ := calendar.NewBuilder(calendar.Config{
+		Name:     "Redbridge Collections",
+		Timezone: "Europe/London",
+	})
+	cfg := config.Config{
+		ListenAddr:   ":0",
+		CacheTTL:     time.Hour,
+		Timezone:     "Europe/London",
+		CalendarName: "Redbridge Collections",
+	}
+	srv := mustNew(t, cfg, s, cal, logger)
+
+	rr := httptest.NewRecorder()
+	srv.calendarHandler(rr, httptest.NewRequest("GET", "/calendar.ics", nil))
+	if rr.Code != 200 {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Content-Disposition"); got != "" {
+		t.Fatalf("expected no Content-Disposition for subscriptions, got %q", got)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.calendarHandler(rr, httptest.NewRequest("GET", "/calendar.ics?download=1", nil))
+	if rr.Code != 200 {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if got, want := rr.Header().Get("Content-Disposition"), `attachment; filename="redbridge-collections.ics"`; got != want {
+		t.Fatalf("Content-Disposition = %q, want %q", got, want)
+	}
+}
+
+func TestCSVHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse"},
+			{Date: mustDate(t, 2025, 12, 8, 6), Type: "Refuse"},
+			{Date: mustDate(t, 2025, 12, 15, 6), Type: "Refuse"},
+		},
+	}
+	cal, _ := calendar.NewBuilder(calendar.Config{
+		Name:          "Redbridge Collections",
+		Timezone:      "Europe/London",
+		UseRecurrence: true,
+	})
+	cfg := config.Config{
+		ListenAddr:   ":0",
+		CacheTTL:     time.Hour,
+		Timezone:     "Europe/London",
+		CalendarName: "Redbridge Collections",
+	}
+	srv := mustNew(t, cfg, s, cal, logger)
+
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/calendar.csv", nil))
+	if rr.Code != 200 {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	if got, want := rr.Header().Get("Content-Disposition"), `attachment; filename="redbridge-collections.csv"`; got != want {
+		t.Fatalf("Content-Disposition = %q, want %q", got, want)
+	}
+
+	if !strings.HasPrefix(rr.Body.String(), "Subject,Start Date,Start Time,End Date,End Time,Description\n") {
+		t.Fatalf("unexpected header in %s", rr.Body.String())
+	}
+	rows, err := csv.NewReader(rr.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("parse csv: %v", err)
+	}
+	// Recurring feeds still get one row per collection.
+	if len(rows) != 4 {
+		t.Fatalf("expected 3 data rows, got %d", len(rows)-1)
+	}
+	want := []string{"Bin: Refuse", "12/01/2025", "06:00 AM", "12/01/2025", "07:00 AM"}
+	if got := rows[1][:5]; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("unexpected data row %q", rows[1])
+	}
+	if !strings.Contains(rows[1][5], "Place bins out by 06:00") {
+		t.Fatalf("unexpected description %q", rows[1][5])
+	}
+}
+
+func TestTypeCalendarHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse"},
+			{Date: mustDate(t, 2025, 12, 2, 6), Type: "Recycling"},
+			{Date: mustDate(t, 2025, 12, 9, 6), Type: "Recycling"},
+			{Date: mustDate(t, 2025, 12, 3, 6), Type: "Garden Waste"},
+		},
+	}
+	cal, _ := calendar.NewBuilder(calendar.Config{
+		Name:     "Redbridge Collections",
+		Timezone: "Europe/London",
+	})
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, cal, logger)
+
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/calendar/recycling.ics", nil))
+	if rr.Code != 200 {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/calendar") {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	body := rr.Body.String()
+	if got := strings.Count(body, "UID:recycling-"); got != 2 {
+		t.Fatalf("expected 2 recycling events, got %d in %s", got, body)
+	}
+	if strings.Contains(body, "UID:refuse-") || strings.Contains(body, "UID:garden-waste-") {
+		t.Fatalf("expected only recycling events, got %s", body)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/calendar/garden-waste.ics", nil))
+	if rr.Code != 200 || !strings.Contains(rr.Body.String(), "UID:garden-waste-20251203@redbridge-ics") {
+		t.Fatalf("expected garden waste feed, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	for _, path := range []string{"/calendar/food.ics", "/calendar/recycling.txt"} {
+		rr = httptest.NewRecorder()
+		srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		if rr.Code != http.StatusNotFound {
+			t.Fatalf("%s: expected 404, got %d", path, rr.Code)
+		}
+	}
+}
+
+func TestHorizonFiltersCalendarAndSchedule(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	year, month, day := time.Now().Date()
+	near := mustDate(t, year, month, day+7, 6)
+	far := mustDate(t, year, month, day+35, 6)
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: near, Type: "Refuse"},
+			{Date: far, Type: "Recycling"},
+		},
+	}
+	cal, _ := calendar.NewBuilder(calendar.Config{
+		Name:     "Redbridge Collections",
+		Timezone: "Europe/London",
+	})
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+		Horizon:    21 * 24 * time.Hour,
+	}
+	srv := mustNew(t, cfg, s, cal, logger)
+
+	rr := httptest.NewRecorder()
+	srv.calendarHandler(rr, httptest.NewRequest("GET", "/calendar.ics", nil))
+	body := rr.Body.String()
+	if !strings.Contains(body, "UID:refuse-"+near.Format("20060102")+"@redbridge-ics") {
+		t.Fatalf("expected collection inside the horizon in feed")
+	}
+	if strings.Contains(body, "UID:recycling-") {
+		t.Fatalf("expected collection beyond the horizon to be excluded")
+	}
+
+	rr = httptest.NewRecorder()
+	srv.scheduleHandler(rr, httptest.NewRequest("GET", "/api/schedule", nil))
+	var payload []struct {
+		Date string `json:"date"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(payload) != 1 || payload[0].Date != near.Format("2006-01-02") {
+		t.Fatalf("expected only the near collection day, got %+v", payload)
+	}
+}
+
+func TestCalendarUIDIncludesUPRN(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	collections := []scraper.Collection{{Date: mustDate(t, 2025, 12, 2, 6), Type: "Refuse"}}
+	cal, err := calendar.NewBuilder(calendar.Config{Name: "Redbridge Collections", Timezone: "Europe/London"})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+	cfg := config.Config{
+		ListenAddr:     ":0",
+		UPRN:           "111",
+		CacheTTL:       time.Hour,
+		Timezone:       "Europe/London",
+		UIDIncludeUPRN: true,
+	}
+	srv := mustNew(t, cfg, &fakeScraper{collections: collections}, cal, logger)
+	srv.AddAddress("222", &fakeScraper{collections: collections})
+
+	for _, uprn := range []string{"111", "222"} {
+		rr := httptest.NewRecorder()
+		srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/calendar.ics?uprn="+uprn, nil))
+		if want := "UID:refuse-20251202-" + uprn + "@redbridge-ics"; !strings.Contains(rr.Body.String(), want) {
+			t.Fatalf("expected %q, got %s", want, rr.Body.String())
+		}
+	}
+}
+
+func TestEventsHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 2, 6), Type: "Refuse"},
+			{Date: mustDate(t, 2025, 12, 2, 6), Type: "Recycling"},
+			{Date: mustDate(t, 2025, 12, 9, 6), Type: "Refuse"},
+		},
+	}
+	cal, err := calendar.NewBuilder(calendar.Config{
+		Name:     "Redbridge Collections",
+		Timezone: "Europe/London",
+		Alarms:   []string{"-PT30M"},
+	})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, cal, logger)
+
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/events", nil))
+	if rr.Code != 200 {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var events []struct {
+		UID        string   `json:"uid"`
+		Summary    string   `json:"summary"`
+		Start      string   `json:"start"`
+		End        string   `json:"end"`
+		Categories []string `json:"categories"`
+		Alarms     []string `json:"alarms"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &events); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/calendar.ics", nil))
+	ics := rr.Body.String()
+	if got := strings.Count(ics, "BEGIN:VEVENT"); got != len(events) {
+		t.Fatalf("expected %d events to match the feed, got %d", got, len(events))
+	}
+	for _, e := range events {
+		if !strings.Contains(ics, "UID:"+e.UID) {
+			t.Fatalf("event %s missing from feed", e.UID)
+		}
+		if !strings.Contains(ics, "SUMMARY:"+e.Summary) {
+			t.Fatalf("summary %q missing from feed", e.Summary)
+		}
+		if len(e.Categories) != 1 || len(e.Alarms) != 1 || e.Alarms[0] != "-PT30M" {
+			t.Fatalf("unexpected event %+v", e)
+		}
+	}
+	if events[0].UID != calendar.EventID(s.collections[0]) || events[0].Start != "2025-12-02T06:00:00Z" || events[0].End != "2025-12-02T07:00:00Z" {
+		t.Fatalf("unexpected first event %+v", events[0])
+	}
+
+	srv = mustNew(t, cfg, s, &noopCalendar{}, logger)
+	rr = httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/events", nil))
+	if rr.Code != 501 {
+		t.Fatalf("expected 501 without event support, got %d", rr.Code)
+	}
+}
+
+func TestCalendarContentNegotiation(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 2, 6), Type: "Refuse"},
+			{Date: mustDate(t, 2025, 12, 9, 6), Type: "Recycling"},
+		},
+	}
+	cal, err := calendar.NewBuilder(calendar.Config{Name: "Redbridge Collections", Timezone: "Europe/London"})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+	cfg := config.Config{ListenAddr: ":0", CacheTTL: time.Hour, Timezone: "Europe/London"}
+	srv := mustNew(t, cfg, s, cal, logger)
+
+	get := func(accept string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/calendar.ics?types=Refuse", nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rr := httptest.NewRecorder()
+		srv.httpServer.Handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Accept %q: expected 200, got %d", accept, rr.Code)
+		}
+		if !strings.Contains(rr.Header().Get("Vary"), "Accept") {
+			t.Fatalf("Accept %q: expected Vary: Accept, got %q", accept, rr.Header().Get("Vary"))
+		}
+		return rr
+	}
+
+	rr := get("application/json")
+	if got := rr.Header().Get("Content-Type"); !strings.HasPrefix(got, "application/json") {
+		t.Fatalf("expected JSON, got %q", got)
+	}
+	var events []struct {
+		UID        string   `json:"uid"`
+		Categories []string `json:"categories"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &events); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(events) != 1 || events[0].UID != calendar.EventID(s.collections[0]) {
+		t.Fatalf("expected the filtered Refuse event, got %+v", events)
+	}
+
+	for _, accept := range []string{
+		"",
+		"*/*",
+		"text/calendar",
+		"text/calendar, application/json",
+		"application/json;q=0.5, text/calendar",
+		"application/json;q=0.9, */*;q=0.9",
+	} {
+		rr := get(accept)
+		if got := rr.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/calendar") {
+			t.Fatalf("Accept %q: expected ICS, got %q", accept, got)
+		}
+		if !strings.Contains(rr.Body.String(), "BEGIN:VCALENDAR") {
+			t.Fatalf("Accept %q: expected a calendar body", accept)
+		}
+	}
+
+	for _, accept := range []string{"application/json, */*;q=0.8", "application/*", "text/calendar;q=0.1, application/json"} {
+		if got := get(accept).Header().Get("Content-Type"); !strings.HasPrefix(got, "application/json") {
+			t.Fatalf("Accept %q: expected JSON, got %q", accept, got)
+		}
+	}
+}
+
+func TestSoonHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse"},
+			{Date: mustDate(t, 2025, 12, 3, 6), Type: "Recycling"},
+			{Date: mustDate(t, 2025, 12, 3, 6), Type: "Food Waste"},
+			{Date: mustDate(t, 2025, 12, 10, 6), Type: "Refuse"},
+		},
+	}
+	cfg := config.Config{ListenAddr: ":0", CacheTTL: time.Hour, Timezone: "Europe/London"}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+
+	type soonResponse struct {
+		Soon   bool     `json:"soon"`
+		Within string   `json:"within"`
+		Types  []string `json:"types"`
+		Days   []struct {
+			Date      string `json:"date"`
+			DaysUntil int    `json:"days_until"`
+		} `json:"days"`
+	}
+	get := func(query string) soonResponse {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/soon?now=2025-12-01T12:00:00Z&"+query, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", query, rr.Code, rr.Body.String())
+		}
+		var resp soonResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: unmarshal: %v", query, err)
+		}
+		return resp
+	}
+
+	// Nothing tomorrow, but the day after is within two days.
+	rr := httptest.NewRecorder()
+	srv.isTomorrowHandler(rr, httptest.NewRequest("GET", "/api/is-tomorrow?now=2025-12-01T12:00:00Z", nil))
+	if !strings.Contains(rr.Body.String(), `"tomorrow":false`) {
+		t.Fatalf("expected nothing tomorrow, got %s", rr.Body.String())
+	}
+	got := get("within=2d")
+	if !got.Soon || got.Within != "2d" || len(got.Days) != 1 || got.Days[0].Date != "2025-12-03" || got.Days[0].DaysUntil != 2 {
+		t.Fatalf("unexpected 2d response %+v", got)
+	}
+	if len(got.Types) != 2 || !contains(got.Types, "Recycling") || !contains(got.Types, "Food Waste") {
+		t.Fatalf("unexpected types %v", got.Types)
+	}
+	if got := get(""); got.Within != "2d" || !got.Soon {
+		t.Fatalf("expected 2d default, got %+v", got)
+	}
+
+	// Today's collection has passed, so one day finds nothing.
+	if got := get("within=1d"); got.Soon || len(got.Types) != 0 || len(got.Days) != 0 {
+		t.Fatalf("expected nothing within 1d, got %+v", got)
+	}
+
+	// Durations measure to the start time: 06:00 on the 3rd is 42h away.
+	if got := get("within=36h"); got.Soon {
+		t.Fatalf("expected nothing within 36h, got %+v", got)
+	}
+	if got := get("within=48h"); !got.Soon {
+		t.Fatalf("expected a collection within 48h, got %+v", got)
+	}
+	if got := get("within=10d"); len(got.Days) != 2 {
+		t.Fatalf("expected 2 days within 10d, got %+v", got)
+	}
+
+	for _, within := range []string{"soon", "-1d", "0s"} {
+		rr := httptest.NewRecorder()
+		srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/soon?within="+within, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", within, rr.Code)
+		}
+	}
+}
+
+func TestFreeBusyHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 2, 6), Type: "Refuse"},
+			{Date: mustDate(t, 2025, 12, 9, 6), Type: "Recycling"},
+		},
+	}
+	cal, err := calendar.NewBuilder(calendar.Config{Name: "Redbridge Collections", Timezone: "Europe/London"})
+	if err != nil {
+		t.Fatalf("NewBuilder: %v", err)
+	}
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, cal, logger)
+
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/freebusy.ifb?dtstart=2025-12-01&dtend=2025-12-05", nil))
+	if rr.Code != 200 {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Content-Type"); got != "text/calendar; charset=utf-8" {
+		t.Fatalf("unexpected content-type %s", got)
+	}
+	body := rr.Body.String()
+	if !strings.Contains(body, "BEGIN:VFREEBUSY") || !strings.Contains(body, "FREEBUSY;FBTYPE=BUSY:20251202T060000Z/20251202T070000Z") {
+		t.Fatalf("expected VFREEBUSY with a busy period, got %s", body)
+	}
+	if strings.Contains(body, "20251209T") {
+		t.Fatalf("expected collections after dtend to be excluded")
+	}
+
+	rr = httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/freebusy.ifb?dtstart=2025-12-05&dtend=2025-12-01", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an inverted range, got %d", rr.Code)
+	}
+}
+
+func TestCalendarHandlerEmptySchedule(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{collections: []scraper.Collection{}}
+	cal, _ := calendar.NewBuilder(calendar.Config{
+		Name:     "Redbridge Collections",
+		Timezone: "Europe/London",
+	})
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, cal, logger)
+
+	rr := httptest.NewRecorder()
+	srv.calendarHandler(rr, httptest.NewRequest("GET", "/calendar.ics", nil))
+	if rr.Code != 200 {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("X-Empty-Schedule"); got != "true" {
+		t.Fatalf("expected X-Empty-Schedule header, got %q", got)
+	}
+	body := rr.Body.String()
+	if !strings.Contains(body, "BEGIN:VCALENDAR") || strings.Contains(body, "BEGIN:VEVENT") {
+		t.Fatalf("expected empty VCALENDAR, got %s", body)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.calendarHandler(rr, httptest.NewRequest("GET", "/calendar.ics?empty=204", nil))
+	if rr.Code != 204 {
+		t.Fatalf("expected 204 with empty=204, got %d", rr.Code)
+	}
+	if rr.Body.Len() != 0 {
+		t.Fatalf("expected no body, got %q", rr.Body.String())
+	}
+}
+
+func TestCalendarHandlerConditional(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(httptest.NewRecorder(), nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse"},
+		},
+	}
+	cal := &fakeCalendarBuilder{ics: []byte("BEGIN:VCALENDAR\nEND:VCALENDAR")}
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, cal, logger)
+
+	req := httptest.NewRequest("GET", "/calendar.ics", nil)
+	rr := httptest.NewRecorder()
+	srv.calendarHandler(rr, req)
+
+	etag := rr.Header().Get("ETag")
+	lastModified := rr.Header().Get("Last-Modified")
+	if etag == "" || lastModified == "" {
+		t.Fatalf("expected ETag and Last-Modified, got %q and %q", etag, lastModified)
+	}
+
+	req = httptest.NewRequest("GET", "/calendar.ics", nil)
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	srv.calendarHandler(rr, req)
+
+	if rr.Code != 304 {
+		t.Fatalf("expected 304 for matching ETag, got %d", rr.Code)
+	}
+	if rr.Body.Len() != 0 {
+		t.Fatalf("expected empty body on 304, got %q", rr.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/calendar.ics", nil)
+	req.Header.Set("If-Modified-Since", lastModified)
+	rr = httptest.NewRecorder()
+	srv.calendarHandler(rr, req)
+
+	if rr.Code != 304 {
+		t.Fatalf("expected 304 for If-Modified-Since, got %d", rr.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/calendar.ics", nil)
+	req.Header.Set("If-None-Match", `"stale"`)
+	rr = httptest.NewRecorder()
+	srv.calendarHandler(rr, req)
+
+	if rr.Code != 200 {
+		t.Fatalf("expected 200 for mismatched ETag, got %d", rr.Code)
+	}
+}
+
+func TestNotModifiedSinceFetch(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse"},
+		},
+	}
+	cal := &fakeCalendarBuilder{ics: []byte("BEGIN:VCALENDAR\nEND:VCALENDAR")}
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, cal, logger)
+
+	rr := httptest.NewRecorder()
+	srv.calendarHandler(rr, httptest.NewRequest("GET", "/calendar.ics", nil))
+	lastModified := rr.Header().Get("Last-Modified")
+	if rr.Code != 200 || lastModified == "" {
+		t.Fatalf("expected 200 with Last-Modified, got %d and %q", rr.Code, lastModified)
+	}
+
+	for _, path := range []string{"/calendar.ics", "/api/schedule"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("If-Modified-Since", lastModified)
+		rr = httptest.NewRecorder()
+		srv.httpServer.Handler.ServeHTTP(rr, req)
+		if rr.Code != 304 {
+			t.Fatalf("%s: expected 304, got %d", path, rr.Code)
+		}
+		if rr.Header().Get("Last-Modified") != lastModified || rr.Header().Get("Cache-Control") == "" {
+			t.Fatalf("%s: expected Last-Modified and Cache-Control on 304, got %v", path, rr.Header())
+		}
+	}
+	if cal.calls != 1 || s.calls != 1 {
+		t.Fatalf("expected 304s without building or scraping, got %d builds and %d scrapes", cal.calls, s.calls)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.scheduleHandler(rr, httptest.NewRequest("GET", "/api/schedule", nil))
+	if rr.Header().Get("Last-Modified") != lastModified {
+		t.Fatalf("expected schedule Last-Modified %q, got %q", lastModified, rr.Header().Get("Last-Modified"))
+	}
+
+	// now= changes days_until, so it is always answered in full.
+	req := httptest.NewRequest("GET", "/api/schedule?now=2025-11-30", nil)
+	req.Header.Set("If-Modified-Since", lastModified)
+	rr = httptest.NewRecorder()
+	srv.scheduleHandler(rr, req)
+	if rr.Code != 200 {
+		t.Fatalf("expected 200 with now, got %d", rr.Code)
+	}
+
+	// An expired cache would be re-scraped, so it can't vouch for the client.
+	srv.primary.cache.mu.Lock()
+	srv.primary.cache.fetched = time.Now().Add(-2 * time.Hour)
+	srv.primary.cache.mu.Unlock()
+	req = httptest.NewRequest("GET", "/calendar.ics", nil)
+	req.Header.Set("If-Modified-Since", time.Now().UTC().Format(http.TimeFormat))
+	rr = httptest.NewRecorder()
+	srv.calendarHandler(rr, req)
+	if s.calls != 2 {
+		t.Fatalf("expected a re-scrape for an expired cache, got %d scrapes", s.calls)
+	}
+}
+
+func TestWebcalHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(httptest.NewRecorder(), nil))
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, &fakeScraper{}, &noopCalendar{}, logger)
+
+	req := httptest.NewRequest("GET", "http://10.0.0.5:8080/calendar.webcal?types=Refuse", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "bins.example.com, proxy.internal")
+	rr := httptest.NewRecorder()
+	srv.webcalHandler(rr, req)
+
+	if rr.Code != 302 {
+		t.Fatalf("expected 302, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Location"); got != "webcal://bins.example.com/calendar.ics?types=Refuse" {
+		t.Fatalf("unexpected location %s", got)
+	}
+
+	req = httptest.NewRequest("GET", "http://10.0.0.5:8080/calendar.webcal", nil)
+	rr = httptest.NewRecorder()
+	srv.webcalHandler(rr, req)
+	if got := rr.Header().Get("Location"); got != "webcal://10.0.0.5:8080/calendar.ics" {
+		t.Fatalf("unexpected location without proxy headers %s", got)
+	}
+}
+
+func TestFeedsHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{}
+	cfg := config.Config{
+		ListenAddr:   ":0",
+		UPRN:         "123",
+		CacheTTL:     time.Hour,
+		Timezone:     "Europe/London",
+		CalendarName: "Redbridge Collections",
+		CalendarDesc: "Household waste & recycling (scraped)",
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+	srv.primary.cache.Set([]scraper.Collection{
+		{Date: mustDate(t, 2025, 12, 2, 6), Type: "Refuse"},
+		{Date: mustDate(t, 2025, 12, 2, 6), Type: "Garden Waste"},
+	})
+
+	req := httptest.NewRequest("GET", "/api/feeds", nil)
+	req.Host = "bins.example.com"
+	req.Header.Set("X-Forwarded-Proto", "https")
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, req)
+
+	if rr.Code != 200 {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if s.calls != 0 {
+		t.Fatalf("expected no scrape, scraper called %d times", s.calls)
+	}
+
+	var resp struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		ICS         string `json:"ics"`
+		Webcal      string `json:"webcal"`
+		Types       []struct {
+			Type   string `json:"type"`
+			ICS    string `json:"ics"`
+			Webcal string `json:"webcal"`
+		} `json:"types"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Name != cfg.CalendarName || resp.Description != cfg.CalendarDesc {
+		t.Fatalf("unexpected calendar metadata: %+v", resp)
+	}
+	if resp.ICS != "https://bins.example.com/calendar.ics" {
+		t.Fatalf("unexpected ics URL %q", resp.ICS)
+	}
+	if resp.Webcal != "webcal://bins.example.com/calendar.ics" {
+		t.Fatalf("unexpected webcal URL %q", resp.Webcal)
+	}
+	if len(resp.Types) != 2 || resp.Types[0].Type != "Garden Waste" {
+		t.Fatalf("unexpected per-type feeds: %+v", resp.Types)
+	}
+	if resp.Types[0].ICS != "https://bins.example.com/calendar.ics?types=Garden+Waste" {
+		t.Fatalf("unexpected per-type ics URL %q", resp.Types[0].ICS)
+	}
+	if resp.Types[1].Webcal != "webcal://bins.example.com/calendar.ics?types=Refuse" {
+		t.Fatalf("unexpected per-type webcal URL %q", resp.Types[1].Webcal)
+	}
+}
+
+func TestStreamHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	year, month, day := time.Now().Date()
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, year, month, day+1, 6), Type: "Refuse"},
+		},
+	}
+	cfg := config.Config{
+		ListenAddr:     ":0",
+		CacheTTL:       time.Hour,
+		Timezone:       "Europe/London",
+		StreamInterval: time.Hour,
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+	ts := httptest.NewServer(srv.httpServer.Handler)
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", ts.URL+"/api/stream", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	var event, data string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		if line == "" {
+			break
+		}
+		if v, ok := strings.CutPrefix(line, "event: "); ok {
+			event = v
+		}
+		if v, ok := strings.CutPrefix(line, "data: "); ok {
+			data = v
+		}
+	}
+	if event != "types" {
+		t.Fatalf("unexpected event name %q", event)
+	}
+
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(data), &payload); err != nil {
+		t.Fatalf("decode payload %s: %v", data, err)
+	}
+	if len(payload) != 3 {
+		t.Fatalf("unexpected payload keys %s", data)
+	}
+	if string(payload["today"]) != "[]" || string(payload["tomorrow"]) != `["Refuse"]` {
+		t.Fatalf("unexpected payload %s", data)
+	}
+	if _, err := time.Parse(`"2006-01-02"`, string(payload["date"])); err != nil {
+		t.Fatalf("unexpected date in %s", data)
+	}
+}
+
+func TestAuthToken(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 2, 6), Type: "Refuse"},
+		},
+	}
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+		AuthToken:  "s3cret",
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+
+	cases := []struct {
+		name   string
+		target string
+		header string
+		code   int
+	}{
+		{"missing token", "/calendar.ics", "", 401},
+		{"wrong bearer", "/calendar.ics", "Bearer nope", 401},
+		{"wrong query", "/calendar.ics?token=nope", "", 401},
+		{"bearer", "/calendar.ics", "Bearer s3cret", 200},
+		{"query", "/calendar.ics?token=s3cret", "", 200},
+		{"healthz open", "/healthz", "", 200},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest("GET", tc.target, nil)
+		if tc.header != "" {
+			req.Header.Set("Authorization", tc.header)
+		}
+		rr := httptest.NewRecorder()
+		srv.httpServer.Handler.ServeHTTP(rr, req)
+		if rr.Code != tc.code {
+			t.Fatalf("%s: expected %d, got %d", tc.name, tc.code, rr.Code)
+		}
+		if tc.code == 401 && rr.Header().Get("WWW-Authenticate") == "" {
+			t.Fatalf("%s: expected WWW-Authenticate header", tc.name)
+		}
+	}
+}
+
+func TestCORS(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{collections: []scraper.Collection{{Date: time.Now().AddDate(0, 0, 2), Type: "Refuse"}}}
+	cfg := config.Config{
+		ListenAddr:     ":0",
+		CacheTTL:       time.Hour,
+		Timezone:       "Europe/London",
+		AuthToken:      "secret",
+		AllowedOrigins: []string{"https://dash.example.com"},
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+
+	req := httptest.NewRequest("OPTIONS", "/api/next", nil)
+	req.Header.Set("Origin", "https://dash.example.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	req.Header.Set("Access-Control-Request-Headers", "Authorization")
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 preflight without a token, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "https://dash.example.com" {
+		t.Fatalf("unexpected allow-origin %q", got)
+	}
+	if !strings.Contains(rr.Header().Get("Access-Control-Allow-Methods"), "GET") ||
+		!strings.Contains(rr.Header().Get("Access-Control-Allow-Headers"), "Authorization") {
+		t.Fatalf("missing preflight allow headers: %v", rr.Header())
+	}
+
+	req = httptest.NewRequest("GET", "/api/next", nil)
+	req.Header.Set("Origin", "https://dash.example.com")
+	req.Header.Set("Authorization", "Bearer secret")
+	rr = httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != 200 || rr.Header().Get("Access-Control-Allow-Origin") != "https://dash.example.com" {
+		t.Fatalf("expected CORS headers on allowed origin, got %d %v", rr.Code, rr.Header())
+	}
+
+	req = httptest.NewRequest("GET", "/api/next", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	req.Header.Set("Authorization", "Bearer secret")
+	rr = httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, req)
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("expected no CORS headers for a disallowed origin, got %q", got)
+	}
+
+	req = httptest.NewRequest("OPTIONS", "/api/next", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	rr = httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code == http.StatusNoContent || rr.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("expected disallowed preflight to be refused, got %d", rr.Code)
+	}
+}
+
+func TestCompressedCalendar(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(httptest.NewRecorder(), nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse"},
+		},
+	}
+	ics := "BEGIN:VCALENDAR\n" + strings.Repeat("BEGIN:VEVENT\nSUMMARY:Bin: Refuse\nEND:VEVENT\n", 100) + "END:VCALENDAR"
+	cal := &fakeCalendarBuilder{ics: []byte(ics)}
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, cal, logger)
+
+	req := httptest.NewRequest("GET", "/calendar.ics", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, req)
+
+	if rr.Code != 200 {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("expected gzip encoding, got %q", got)
+	}
+	if etag := rr.Header().Get("ETag"); !strings.HasPrefix(etag, "W/") {
+		t.Fatalf("expected weak ETag on compressed response, got %q", etag)
+	}
+
+	zr, err := gzip.NewReader(rr.Body)
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	plain, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("decompress: %v", err)
+	}
+	if string(plain) != ics {
+		t.Fatalf("decompressed body does not match original ICS")
+	}
+
+	req = httptest.NewRequest("GET", "/healthz", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr = httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, req)
+	if got := rr.Header().Get("Content-Encoding"); got != "" {
+		t.Fatalf("expected small /healthz body to stay uncompressed, got %q", got)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	cases := []struct {
+		name       string
+		trustProxy bool
+		headers    map[string]string
+		want       string
+	}{
+		{"remote addr", false, nil, "192.0.2.1"},
+		{"untrusted forwarded header", false, map[string]string{"X-Forwarded-For": "203.0.113.9"}, "192.0.2.1"},
+		{"last forwarded hop", true, map[string]string{"X-Forwarded-For": "10.0.0.1, 203.0.113.9"}, "203.0.113.9"},
+		{"real ip preferred", true, map[string]string{"X-Real-IP": "198.51.100.4", "X-Forwarded-For": "203.0.113.9"}, "198.51.100.4"},
+		{"garbage falls back", true, map[string]string{"X-Forwarded-For": "unknown"}, "192.0.2.1"},
+	}
+	for _, tc := range cases {
+		srv := &Server{cfg: config.Config{TrustProxy: tc.trustProxy}}
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "192.0.2.1:4321"
+		for k, v := range tc.headers {
+			req.Header.Set(k, v)
+		}
+		if got := srv.clientIP(req); got != tc.want {
+			t.Fatalf("%s: expected %s, got %s", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestRefreshAllowedIPs(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{collections: []scraper.Collection{{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse"}}}
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+		TrustProxy: true,
+		AllowedIPs: []netip.Prefix{netip.MustParsePrefix("192.168.1.0/24")},
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+
+	req := httptest.NewRequest("POST", "/api/refresh", nil)
+	req.Header.Set("X-Forwarded-For", "203.0.113.9")
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 outside the allowlist, got %d", rr.Code)
+	}
+	if s.calls != 0 {
+		t.Fatalf("expected no scrape for a denied refresh, got %d", s.calls)
+	}
+
+	req = httptest.NewRequest("POST", "/api/refresh", nil)
+	req.Header.Set("X-Forwarded-For", "192.168.1.20")
+	rr = httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 inside the allowlist, got %d", rr.Code)
+	}
+}
+
+func TestRequestLogging(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, &fakeScraper{}, &noopCalendar{}, logger)
+
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/healthz", nil))
+
+	id := rr.Header().Get("X-Request-Id")
+	if len(id) != 36 {
+		t.Fatalf("expected generated UUID request ID, got %q", id)
+	}
+	line := logs.String()
+	if !strings.Contains(line, `"request_id":"`+id+`"`) {
+		t.Fatalf("expected request ID in log, got %s", line)
+	}
+	if !strings.Contains(line, `"path":"/healthz"`) || !strings.Contains(line, `"status":200`) {
+		t.Fatalf("expected path and status in log, got %s", line)
+	}
+
+	req := httptest.NewRequest("GET", "/healthz", nil)
+	req.Header.Set("X-Request-Id", "abc-123")
+	rr = httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, req)
+	if got := rr.Header().Get("X-Request-Id"); got != "abc-123" {
+		t.Fatalf("expected incoming request ID to be reused, got %q", got)
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(httptest.NewRecorder(), nil))
+	s := &fakeScraper{collections: []scraper.Collection{}}
+	cal := &fakeCalendarBuilder{}
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, cal, logger)
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	rr := httptest.NewRecorder()
+	srv.metrics.handler().ServeHTTP(rr, req)
+
+	if rr.Code != 200 {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "redbridge_cache_hits_total") {
+		t.Fatalf("expected metrics output, got %s", rr.Body.String())
+	}
+}
+
+func TestMetricsCountScrapes(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(httptest.NewRecorder(), nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse"},
+		},
+	}
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/next?now=2025-11-30T12:00:00Z", nil))
+	if rr.Code != 200 {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	if rr.Code != 200 {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "\nredbridge_scrapes_total 1\n") {
+		t.Fatalf("expected one recorded scrape, got %s", rr.Body.String())
+	}
+}
+
+func TestMetricsCollectionsByType(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse"},
+			{Date: mustDate(t, 2025, 12, 8, 6), Type: "Refuse"},
+			{Date: mustDate(t, 2025, 12, 1, 6), Type: "Garden Waste"},
+		},
+	}
+	rental := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 3, 6), Type: "Recycling"},
+		},
+	}
+	cfg := config.Config{
+		ListenAddr: ":0",
+		UPRN:       "111",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+	srv.AddAddress("222", rental)
+
+	scrape := func(addr *address) string {
+		t.Helper()
+		if _, err := srv.collections(context.Background(), addr, true); err != nil {
+			t.Fatalf("collections: %v", err)
+		}
+		rr := httptest.NewRecorder()
+		srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+		return rr.Body.String()
+	}
+
+	body := scrape(srv.primary)
+	if !strings.Contains(body, `redbridge_collections{type="Refuse",uprn="111"} 2`) {
+		t.Fatalf("expected refuse gauge of 2, got %s", body)
+	}
+	if !strings.Contains(body, `redbridge_collections{type="Garden Waste",uprn="111"} 1`) {
+		t.Fatalf("expected garden gauge of 1, got %s", body)
+	}
+
+	// Another address's scrape leaves the first one's counts alone.
+	body = scrape(srv.addresses["222"])
+	if !strings.Contains(body, `redbridge_collections{type="Recycling",uprn="222"} 1`) {
+		t.Fatalf("expected recycling gauge of 1 for the second address, got %s", body)
+	}
+	if !strings.Contains(body, `redbridge_collections{type="Refuse",uprn="111"} 2`) ||
+		strings.Contains(body, `redbridge_collections{type="Refuse",uprn="222"}`) {
+		t.Fatalf("expected the first address's gauges untouched, got %s", body)
+	}
+
+	s.collections = s.collections[:2]
+	body = scrape(srv.primary)
+	if !strings.Contains(body, `redbridge_collections{type="Garden Waste",uprn="111"} 0`) {
+		t.Fatalf("expected garden gauge to drop to 0, got %s", body)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{err: scraper.ErrAddressSetup}
+	cfg := config.Config{
+		ListenAddr:       ":0",
+		CacheTTL:         time.Hour,
+		Timezone:         "Europe/London",
+		BreakerThreshold: 2,
+		BreakerCooldown:  time.Hour,
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := srv.collections(ctx, srv.primary, false); !errors.Is(err, scraper.ErrAddressSetup) {
+			t.Fatalf("attempt %d: expected scrape error, got %v", i, err)
+		}
+	}
+	if s.calls != 2 {
+		t.Fatalf("expected 2 scrapes before opening, got %d", s.calls)
+	}
+
+	// Open: fail fast with the last error, without touching the origin.
+	for i := 0; i < 3; i++ {
+		_, err := srv.collections(ctx, srv.primary, true)
+		if !errors.Is(err, errCircuitOpen) || !errors.Is(err, scraper.ErrAddressSetup) {
+			t.Fatalf("expected open breaker wrapping the last error, got %v", err)
+		}
+	}
+	if s.calls != 2 {
+		t.Fatalf("expected no scrapes while open, got %d", s.calls)
+	}
+	rr := httptest.NewRecorder()
+	srv.calendarHandler(rr, httptest.NewRequest("GET", "/calendar.ics", nil))
+	if rr.Code != http.StatusBadGateway || !strings.Contains(rr.Body.String(), "address_setup_failed") {
+		t.Fatalf("expected 502 address_setup_failed while open, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	// Half-open: once the cooldown passes, a failed probe reopens it.
+	srv.primary.breaker.openUntil = time.Now().Add(-time.Second)
+	if _, err := srv.collections(ctx, srv.primary, true); errors.Is(err, errCircuitOpen) {
+		t.Fatalf("expected a probe scrape after cooldown, got %v", err)
+	}
+	if _, err := srv.collections(ctx, srv.primary, true); !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("expected breaker to reopen after failed probe, got %v", err)
+	}
+	if s.calls != 3 {
+		t.Fatalf("expected exactly one probe, got %d scrapes", s.calls)
+	}
+
+	// A successful probe closes it again.
+	srv.primary.breaker.openUntil = time.Now().Add(-time.Second)
+	s.err = nil
+	s.collections = []scraper.Collection{{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse"}}
+	for i := 0; i < 2; i++ {
+		if _, err := srv.collections(ctx, srv.primary, true); err != nil {
+			t.Fatalf("expected recovery, got %v", err)
+		}
+	}
+	if s.calls != 5 {
+		t.Fatalf("expected scrapes to resume after recovery, got %d", s.calls)
+	}
+}
+
+func TestCircuitBreakerCountsTimedOutScrape(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	var inFlight, peak atomic.Int32
+	s := &blockingScraper{inFlight: &inFlight, peak: &peak, delay: time.Second}
+	cfg := config.Config{
+		ListenAddr:       ":0",
+		CacheTTL:         time.Hour,
+		Timezone:         "Europe/London",
+		RequestTimeout:   10 * time.Millisecond,
+		BreakerThreshold: 1,
+		BreakerCooldown:  time.Hour,
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+	ctx := context.Background()
+
+	if _, err := srv.collections(ctx, srv.primary, true); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the shared scrape to time out, got %v", err)
+	}
+	if _, err := srv.collections(ctx, srv.primary, true); !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("expected the timeout to open the breaker, got %v", err)
+	}
+	if got := s.calls.Load(); got != 1 {
+		t.Fatalf("expected one scrape, got %d", got)
+	}
+}
+
+func TestCircuitBreakerPerAddress(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	broken := &fakeScraper{err: scraper.ErrAddressSetup}
+	healthy := &fakeScraper{collections: []scraper.Collection{{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse"}}}
+	cfg := config.Config{
+		ListenAddr:       ":0",
+		UPRN:             "111",
+		CacheTTL:         time.Hour,
+		Timezone:         "Europe/London",
+		BreakerThreshold: 1,
+		BreakerCooldown:  time.Hour,
+	}
+	srv := mustNew(t, cfg, broken, &noopCalendar{}, logger)
+	srv.AddAddress("222", healthy)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		srv.collections(ctx, srv.primary, true)
+	}
+	if _, err := srv.collections(ctx, srv.primary, true); !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("expected the misconfigured address's breaker to open, got %v", err)
+	}
+	if _, err := srv.collections(ctx, srv.addresses["222"], true); err != nil {
+		t.Fatalf("expected the healthy address to keep scraping, got %v", err)
+	}
+	if healthy.calls != 1 {
+		t.Fatalf("expected one scrape of the healthy address, got %d", healthy.calls)
+	}
+}
+
+func TestDiffCollections(t *testing.T) {
+	previous := []scraper.Collection{
+		{Date: mustDate(t, 2025, 12, 22, 6), Type: "Refuse"},
+		{Date: mustDate(t, 2025, 12, 25, 6), Type: "Recycling"},
+		{Date: mustDate(t, 2025, 12, 29, 6), Type: "Refuse"},
+		{Date: mustDate(t, 2025, 12, 30, 6), Type: "Garden Waste"},
+	}
+	current := []scraper.Collection{
+		{Date: mustDate(t, 2025, 12, 22, 6), Type: "Refuse"},
+		{Date: mustDate(t, 2025, 12, 27, 6), Type: "Recycling"},
+		{Date: mustDate(t, 2025, 12, 29, 6), Type: "Refuse"},
+		{Date: mustDate(t, 2026, 1, 5, 6), Type: "Refuse"},
+	}
+
+	if changes := diffCollections(previous, previous); len(changes) != 0 {
+		t.Fatalf("expected no changes for identical scrapes, got %+v", changes)
+	}
+
+	changes := diffCollections(previous, current)
+	want := []Change{
+		{Kind: ChangeMoved, Type: "Recycling", From: mustDate(t, 2025, 12, 25, 6), To: mustDate(t, 2025, 12, 27, 6)},
+		{Kind: ChangeRemoved, Type: "Garden Waste", From: mustDate(t, 2025, 12, 30, 6)},
+		{Kind: ChangeAdded, Type: "Refuse", To: mustDate(t, 2026, 1, 5, 6)},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("expected %d changes, got %+v", len(want), changes)
+	}
+	for i, c := range changes {
+		if c.Kind != want[i].Kind || c.Type != want[i].Type || !c.From.Equal(want[i].From) || !c.To.Equal(want[i].To) {
+			t.Fatalf("change %d = %+v, want %+v", i, c, want[i])
+		}
+	}
+
+	// A shift of a week or more is a different collection, not a move.
+	changes = diffCollections(
+		[]scraper.Collection{{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse"}},
+		[]scraper.Collection{{Date: mustDate(t, 2025, 12, 8, 6), Type: "Refuse"}},
+	)
+	if len(changes) != 2 || changes[0].Kind != ChangeRemoved || changes[1].Kind != ChangeAdded {
+		t.Fatalf("expected removal and addition, got %+v", changes)
+	}
+}
+
+func TestFailureWebhook(t *testing.T) {
+	type alert struct {
+		UPRN                string  `json:"uprn"`
+		Error               string  `json:"error"`
+		ConsecutiveFailures int     `json:"consecutive_failures"`
+		LastSuccess         *string `json:"last_success"`
+	}
+	alerts := make(chan alert, 4)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var a alert
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected webhook request %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+			t.Errorf("decode webhook body: %v", err)
+		}
+		alerts <- a
+	}))
+	defer hook.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &fakeScraper{err: scraper.ErrAddressSetup}
+	rental := &fakeScraper{collections: []scraper.Collection{{Date: mustDate(t, 2025, 12, 3, 6), Type: "Recycling"}}}
+	cfg := config.Config{
+		ListenAddr:       ":0",
+		UPRN:             "111",
+		CacheTTL:         time.Hour,
+		Timezone:         "Europe/London",
+		FailureWebhook:   hook.URL,
+		WebhookThreshold: 3,
+		WebhookTimeout:   time.Second,
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+	srv.AddAddress("222", rental)
+	ctx := context.Background()
+	fail := func(n int) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			if _, err := srv.collections(ctx, srv.primary, true); err == nil {
+				t.Fatalf("expected scrape error")
+			}
+		}
+	}
+	expectNone := func() {
+		t.Helper()
+		select {
+		case a := <-alerts:
+			t.Fatalf("unexpected webhook call %+v", a)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	expectOne := func() alert {
+		t.Helper()
+		select {
+		case a := <-alerts:
+			return a
+		case <-time.After(2 * time.Second):
+			t.Fatalf("webhook was not called")
+		}
+		return alert{}
+	}
+
+	fail(2)
+	expectNone()
+
+	// Another address succeeding doesn't reset this one's count.
+	if _, err := srv.collections(ctx, srv.addresses["222"], true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fail(1)
+	got := expectOne()
+	if got.UPRN != "111" || got.ConsecutiveFailures != 3 || !strings.Contains(got.Error, scraper.ErrAddressSetup.Error()) || got.LastSuccess != nil {
+		t.Fatalf("unexpected alert %+v", got)
+	}
+
+	// Further failures in the same outage don't alert again.
+	fail(3)
+	expectNone()
+
+	// A success re-arms it; the next outage alerts with the success time.
+	s.err = nil
+	if _, err := srv.collections(ctx, srv.primary, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s.err = scraper.ErrAddressSetup
+	fail(3)
+	got = expectOne()
+	if got.ConsecutiveFailures != 3 || got.LastSuccess == nil {
+		t.Fatalf("expected alert with last_success, got %+v", got)
+	}
+}
+
+func TestScheduleChangesLoggedAfterScrape(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	year, month, day := time.Now().Date()
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, year, month, day-7, 6), Type: "Refuse"},
+			{Date: mustDate(t, year, month, day+3, 6), Type: "Refuse"},
+		},
+	}
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+
+	if _, err := srv.collections(context.Background(), srv.primary, true); err != nil {
+		t.Fatalf("collections: %v", err)
+	}
+	// The past collection rolls off and the upcoming one moves a day later.
+	s.collections = []scraper.Collection{
+		{Date: mustDate(t, year, month, day+4, 6), Type: "Refuse"},
+	}
+	if _, err := srv.collections(context.Background(), srv.primary, true); err != nil {
+		t.Fatalf("collections: %v", err)
+	}
+
+	if got := strings.Count(logs.String(), `"msg":"schedule changed"`); got != 1 {
+		t.Fatalf("expected one schedule change logged, got %d in %s", got, logs.String())
+	}
+	if !strings.Contains(logs.String(), `"change":"moved"`) {
+		t.Fatalf("expected a move to be logged, got %s", logs.String())
+	}
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.Contains(rr.Body.String(), `redbridge_schedule_changes_total{change="moved"} 1`) {
+		t.Fatalf("expected schedule change counter, got %s", rr.Body.String())
+	}
+}
+
+func TestMetricsParserUsed(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	s := &fakeScraper{
+		collections: []scraper.Collection{
+			{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse", Source: scraper.SourceFallback},
+			{Date: mustDate(t, 2025, 12, 8, 6), Type: "Refuse", Source: scraper.SourceFallback},
+		},
+	}
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+
+	for i := 0; i < 2; i++ {
+		if _, err := srv.collections(context.Background(), srv.primary, true); err != nil {
+			t.Fatalf("collections: %v", err)
+		}
+	}
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	if body := rr.Body.String(); !strings.Contains(body, `redbridge_parser_used_total{parser="fallback"} 2`) {
+		t.Fatalf("expected fallback parser counter of 2, got %s", body)
+	}
+	if !strings.Contains(logs.String(), `"parser_used":"fallback"`) {
+		t.Fatalf("expected parser_used in scrape log, got %s", logs.String())
+	}
+}
+
+func TestFetchAllBoundedConcurrency(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := config.Config{
+		ListenAddr:        ":0",
+		UPRN:              "1",
+		CacheTTL:          time.Hour,
+		Timezone:          "Europe/London",
+		ScrapeConcurrency: 2,
+	}
+
+	var inFlight, peak atomic.Int32
+	newScraper := func(err error) *blockingScraper {
+		return &blockingScraper{inFlight: &inFlight, peak: &peak, err: err, delay: 20 * time.Millisecond}
+	}
+	srv := mustNew(t, cfg, newScraper(nil), &noopCalendar{}, logger)
+	srv.AddAddress("2", newScraper(nil))
+	srv.AddAddress("3", newScraper(errors.New("council site down")))
+	srv.AddAddress("4", newScraper(nil))
+
+	results := srv.fetchAll(context.Background(), []string{"1", "2", "3", "4", "5"})
+	if len(results) != 5 {
+		t.Fatalf("expected a result per UPRN, got %d", len(results))
+	}
+	for _, uprn := range []string{"1", "2", "4"} {
+		if res := results[uprn]; res.Err != nil || len(res.Collections) != 1 {
+			t.Fatalf("expected success for %s, got %+v", uprn, res)
+		}
+	}
+	if results["3"].Err == nil {
+		t.Fatalf("expected error for failing address")
+	}
+	if !errors.Is(results["5"].Err, errUnknownUPRN) {
+		t.Fatalf("expected unknown UPRN error, got %v", results["5"].Err)
+	}
+	if got := peak.Load(); got != 2 {
+		t.Fatalf("expected two concurrent scrapes at peak, got %d", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	srv.AddAddress("6", newScraper(nil))
+	if res := srv.fetchAll(ctx, []string{"6"})["6"]; !errors.Is(res.Err, context.Canceled) {
+		t.Fatalf("expected cancelled fetch, got %+v", res)
+	}
+}
+
+func TestRefreshLoop(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	scr := &countingScraper{}
+	cfg := config.Config{
+		ListenAddr:      ":0",
+		CacheTTL:        time.Hour,
+		Timezone:        "Europe/London",
+		RefreshInterval: 10 * time.Millisecond,
+	}
+	srv := mustNew(t, cfg, scr, &noopCalendar{}, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		srv.refreshLoop(ctx)
+		close(done)
+	}()
+
+	deadline := time.After(2 * time.Second)
+	for scr.calls.Load() < 3 {
+		select {
+		case <-deadline:
+			t.Fatalf("expected repeated background refreshes, got %d", scr.calls.Load())
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	if _, ok := srv.primary.cache.Get(cfg.CacheTTL); !ok {
+		t.Fatalf("expected background refresh to fill the cache")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("refresh loop did not stop after cancellation")
+	}
+}
+
+func TestShouldScrape(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/London")
+	srv := &Server{
+		cfg:      config.Config{ScrapeDays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}},
+		location: loc,
+	}
+
+	// 2025-12-01 is a Monday.
+	for offset, want := range []bool{true, true, true, true, true, false, false} {
+		day := mustDate(t, 2025, 12, 1+offset, 12)
+		if got := srv.shouldScrape(day); got != want {
+			t.Fatalf("%s: expected %v, got %v", day.Weekday(), want, got)
+		}
+	}
+
+	// Late Friday in UTC is already Saturday morning in London during BST.
+	fridayUTC := time.Date(2025, time.June, 6, 23, 30, 0, 0, time.UTC)
+	if srv.shouldScrape(fridayUTC) {
+		t.Fatalf("expected weekday to be evaluated in the server timezone")
+	}
+
+	srv.cfg.ScrapeDays = nil
+	if !srv.shouldScrape(mustDate(t, 2025, 12, 6, 12)) {
+		t.Fatalf("expected every day allowed when SCRAPE_DAYS is unset")
+	}
+}
+
+func TestMinFreshnessBackgroundRefresh(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &gatedScraper{started: make(chan struct{}, 4), release: make(chan struct{})}
+	cfg := config.Config{
+		ListenAddr:   ":0",
+		CacheTTL:     time.Hour,
+		MinFreshness: time.Minute,
+		Timezone:     "Europe/London",
+	}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+	srv.primary.cache.Set([]scraper.Collection{
+		{Date: mustDate(t, 2025, 12, 1, 6), Type: "Refuse"},
+	})
+	srv.primary.cache.mu.Lock()
+	staleAt := time.Now().Add(-10 * time.Minute)
+	srv.primary.cache.fetched = staleAt
+	srv.primary.cache.mu.Unlock()
+
+	for i := 0; i < 2; i++ {
+		rr := httptest.NewRecorder()
+		start := time.Now()
+		srv.isTodayHandler(rr, httptest.NewRequest("GET", "/api/is-today?now=2025-12-01T05:00:00Z", nil))
+		if rr.Code != 200 || !strings.Contains(rr.Body.String(), `"today":true`) {
+			t.Fatalf("expected cached answer, got %d %s", rr.Code, rr.Body.String())
+		}
+		if took := time.Since(start); took > 500*time.Millisecond {
+			t.Fatalf("response waited for the refresh: %s", took)
+		}
+	}
+
+	select {
+	case <-s.started:
+	case <-time.After(time.Second):
+		t.Fatalf("expected a background refresh to start")
+	}
+	close(s.release)
+
+	deadline := time.Now().Add(time.Second)
+	for !srv.primary.cache.FetchedAt().After(staleAt) {
+		if time.Now().After(deadline) {
+			t.Fatalf("background refresh did not update the cache")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := s.calls.Load(); got != 1 {
+		t.Fatalf("expected a single concurrent refresh, got %d", got)
+	}
+}
+
+type countingScraper struct {
+	calls atomic.Int32
+}
+
+func (c *countingScraper) FetchCollections(ctx context.Context) ([]scraper.Collection, error) {
+	c.calls.Add(1)
+	return []scraper.Collection{{Date: time.Now(), Type: "Refuse"}}, nil
+}
+
+// gatedScraper signals on started and blocks until release is closed.
+type gatedScraper struct {
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func (g *gatedScraper) FetchCollections(ctx context.Context) ([]scraper.Collection, error) {
+	g.calls.Add(1)
+	g.started <- struct{}{}
+	<-g.release
+	return []scraper.Collection{{Date: time.Now(), Type: "Refuse"}}, nil
+}
+
+// blockingScraper holds each fetch open for delay, tracking peak concurrency,
+// and gives up early when the context is cancelled.
+type blockingScraper struct {
+	inFlight *atomic.Int32
+	peak     *atomic.Int32
+	calls    atomic.Int32
+	delay    time.Duration
+	err      error
+}
+
+func (b *blockingScraper) FetchCollections(ctx context.Context) ([]scraper.Collection, error) {
+	b.calls.Add(1)
+	n := b.inFlight.Add(1)
+	defer b.inFlight.Add(-1)
+	for {
+		p := b.peak.Load()
+		if n <= p || b.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+
+	select {
+	case <-time.After(b.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if b.err != nil {
+		return nil, b.err
+	}
+	return []scraper.Collection{{Date: time.Now(), Type: "Refuse"}}, nil
+}
+
+func TestCollectionsSingleFlight(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+
+	for _, scrapeErr := range []error{nil, scraper.ErrAddressSetup} {
+		var inFlight, peak atomic.Int32
+		s := &blockingScraper{inFlight: &inFlight, peak: &peak, delay: 100 * time.Millisecond, err: scrapeErr}
+		srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+
+		const callers = 20
+		var wg sync.WaitGroup
+		errs := make([]error, callers)
+		counts := make([]int, callers)
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				items, err := srv.collections(context.Background(), srv.primary, false)
+				counts[i], errs[i] = len(items), err
+			}()
+		}
+		wg.Wait()
+
+		if got := s.calls.Load(); got != 1 {
+			t.Fatalf("err=%v: expected one shared scrape, got %d", scrapeErr, got)
+		}
+		for i := 0; i < callers; i++ {
+			if scrapeErr != nil {
+				if !errors.Is(errs[i], scrapeErr) {
+					t.Fatalf("caller %d: expected shared error, got %v", i, errs[i])
+				}
+				continue
+			}
+			if errs[i] != nil || counts[i] != 1 {
+				t.Fatalf("caller %d: expected shared result, got %d items, err %v", i, counts[i], errs[i])
+			}
+		}
+	}
+}
+
+func TestCollectionsSingleFlightOutlivesCancelledCaller(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := config.Config{
+		ListenAddr:       ":0",
+		CacheTTL:         time.Hour,
+		Timezone:         "Europe/London",
+		BreakerThreshold: 1,
+		BreakerCooldown:  time.Hour,
+	}
+	var inFlight, peak atomic.Int32
+	s := &blockingScraper{inFlight: &inFlight, peak: &peak, delay: 200 * time.Millisecond}
+	srv := mustNew(t, cfg, s, &noopCalendar{}, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := srv.collections(ctx, srv.primary, false)
+		firstErr <- err
+	}()
+	for s.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	const callers = 5
+	var wg sync.WaitGroup
+	errs := make([]error, callers)
+	counts := make([]int, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			items, err := srv.collections(context.Background(), srv.primary, false)
+			counts[i], errs[i] = len(items), err
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancelled caller to stop waiting, got %v", err)
+	}
+	wg.Wait()
+	for i := 0; i < callers; i++ {
+		if errs[i] != nil || counts[i] != 1 {
+			t.Fatalf("caller %d: expected shared result, got %d items, err %v", i, counts[i], errs[i])
+		}
+	}
+	if got := s.calls.Load(); got != 1 {
+		t.Fatalf("expected one shared scrape, got %d", got)
+	}
+	if _, ok := srv.primary.cache.Get(cfg.CacheTTL); !ok {
+		t.Fatal("expected the shared scrape to fill the cache")
+	}
+	if _, err := srv.collections(context.Background(), srv.primary, true); err != nil {
+		t.Fatalf("expected the breaker to stay closed, got %v", err)
+	}
+}
+
+func TestProblemResponses(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	scr := &fakeScraper{err: scraper.ErrNoCollections}
+	srv := mustNew(t, cfg, scr, &noopCalendar{}, logger)
+
+	cases := []struct {
+		path   string
+		status int
+		code   string
+	}{
+		{"/calendar.ics", http.StatusBadGateway, "failed_to_parse_schedule"},
+		{"/api/next", http.StatusServiceUnavailable, "unavailable"},
+		{"/api/next?now=yesterday", http.StatusBadRequest, "invalid_now"},
+		{"/api/next?uprn=999", http.StatusNotFound, "unknown_uprn"},
+		{"/api/addresses?postcode=IG1+1AA", http.StatusNotImplemented, "lookup_unsupported"},
+		{"/api/validate", http.StatusNotImplemented, "validate_unsupported"},
+		{"/api/events", http.StatusNotImplemented, "events_unsupported"},
+		{"/freebusy.ifb", http.StatusNotImplemented, "freebusy_unsupported"},
+	}
+	for _, tc := range cases {
+		rr := httptest.NewRecorder()
+		srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", tc.path, nil))
+		if rr.Code != tc.status {
+			t.Fatalf("%s: expected %d, got %d", tc.path, tc.status, rr.Code)
+		}
+		if ct := rr.Header().Get("Content-Type"); ct != "application/problem+json" {
+			t.Fatalf("%s: unexpected content type %q", tc.path, ct)
+		}
+		var problem struct {
+			Type   string `json:"type"`
+			Title  string `json:"title"`
+			Status int    `json:"status"`
+			Detail string `json:"detail"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("%s: decode problem: %v", tc.path, err)
+		}
+		if problem.Type != problemTypeBase+tc.code || problem.Status != tc.status ||
+			problem.Title != http.StatusText(tc.status) || problem.Detail == "" {
+			t.Fatalf("%s: unexpected problem %+v", tc.path, problem)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/next?format=legacy", nil))
+	if got := strings.TrimSpace(rr.Body.String()); got != `{"error":"unavailable"}` {
+		t.Fatalf("unexpected legacy body %s", got)
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Fatalf("unexpected legacy content type %q", ct)
+	}
+}
+
+func TestAddressesHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	lookup := &fakeAddressScraper{
+		matches: map[string][]scraper.AddressMatch{
+			"IG1 1AA": {{UPRN: "10023770000", AddressLine: "1 High Road, Ilford, IG1 1AA"}},
+		},
+	}
+	srv := mustNew(t, cfg, lookup, &noopCalendar{}, logger)
+
+	cases := []struct {
+		postcode string
+		code     int
+		body     string
+	}{
+		{"IG1+1AA", 200, `[{"address":"1 High Road, Ilford, IG1 1AA","uprn":"10023770000"}]`},
+		{"IG2+2BB&format=legacy", 404, `{"error":"no_addresses"}`},
+		{"nonsense&format=legacy", 400, `{"error":"invalid_postcode"}`},
+	}
+	for _, tc := range cases {
+		rr := httptest.NewRecorder()
+		srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/addresses?postcode="+tc.postcode, nil))
+		if rr.Code != tc.code {
+			t.Fatalf("%s: expected %d, got %d", tc.postcode, tc.code, rr.Code)
+		}
+		if got := strings.TrimSpace(rr.Body.String()); got != tc.body {
+			t.Fatalf("%s: unexpected body %s", tc.postcode, got)
+		}
+	}
+
+	srv = mustNew(t, cfg, &fakeScraper{}, &noopCalendar{}, logger)
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/addresses?postcode=IG1+1AA", nil))
+	if rr.Code != 501 {
+		t.Fatalf("expected 501 without lookup support, got %d", rr.Code)
+	}
+}
+
+func TestDebugHTMLHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := config.Config{
+		ListenAddr: ":0",
+		CacheTTL:   time.Hour,
+		Timezone:   "Europe/London",
+	}
+	scr := &fakeHTMLScraper{html: []byte("<div class=\"your-collection-schedule-container\"></div>")}
+
+	srv := mustNew(t, cfg, scr, &noopCalendar{}, logger)
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/debug/html", nil))
+	if rr.Code != 404 {
+		t.Fatalf("expected 404 with debug off, got %d", rr.Code)
+	}
+
+	cfg.Debug = true
+	srv = mustNew(t, cfg, scr, &noopCalendar{}, logger)
+	rr = httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/debug/html", nil))
+	if rr.Code != 200 {
+		t.Fatalf("expected 200 with debug on, got %d", rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Fatalf("expected text/html, got %q", ct)
+	}
+	if rr.Body.String() != string(scr.html) {
+		t.Fatalf("expected raw HTML, got %q", rr.Body.String())
+	}
+}
+
+type fakeHTMLScraper struct {
+	fakeScraper
+	html []byte
+}
+
+func (f *fakeHTMLScraper) FetchHTML(ctx context.Context) ([]byte, error) {
+	return f.html, nil
+}
+
+type fakeAddressScraper struct {
+	fakeScraper
+	matches map[string][]scraper.AddressMatch
+}
+
+func (f *fakeAddressScraper) LookupAddresses(ctx context.Context, postcode string) ([]scraper.AddressMatch, error) {
+	if postcode == "nonsense" {
+		return nil, scraper.ErrInvalidPostcode
+	}
+	matches, ok := f.matches[postcode]
+	if !ok {
+		return nil, scraper.ErrNoAddresses
+	}
+	return matches, nil
+}
+
+type fakeScraper struct {
+	collections []scraper.Collection
+	err         error
+	calls       int
+}
+
+func (f *fakeScraper) FetchCollections(ctx context.Context) ([]scraper.Collection, error) {
+	f.calls++
+	return f.collections, f.err
+}
+
+type fakeCalendarBuilder struct {
+	ics   []byte
+	err   error
+	calls int
+}
 
 func (f *fakeCalendarBuilder) Build(collections []scraper.Collection) ([]byte, error) {
+	f.calls++
 	return f.ics, f.err
 }
 
@@ -184,6 +3477,15 @@ func (n *noopCalendar) Build(collections []scraper.Collection) ([]byte, error) {
 	return []byte(""), nil
 }
 
+func mustNew(t *testing.T, cfg config.Config, scr Scraper, cal CalendarBuilder, logger *slog.Logger) *Server {
+	t.Helper()
+	srv, err := New(cfg, scr, cal, logger)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return srv
+}
+
 func mustDate(t *testing.T, year int, month time.Month, day, hour int) time.Time {
 	t.Helper()
 	loc, _ := time.LoadLocation("Europe/London")
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+)
+
+const defaultStreamInterval = time.Minute
+
+// streamSnapshot is the payload of each "types" event sent by /api/stream.
+type streamSnapshot struct {
+	Date     string   `json:"date"`
+	Today    []string `json:"today"`
+	Tomorrow []string `json:"tomorrow"`
+}
+
+func (a streamSnapshot) sameTypes(b streamSnapshot) bool {
+	return slices.Equal(a.Today, b.Today) && slices.Equal(a.Tomorrow, b.Tomorrow)
+}
+
+// streamHandler pushes today/tomorrow collection types as server-sent events:
+// one snapshot on connect, then another whenever the types change. The types
+// are re-evaluated from the cache every cfg.StreamInterval until the client
+// goes away.
+func (s *Server) streamHandler(w http.ResponseWriter, r *http.Request) {
+	addr, ok := s.resolveAddress(w, r)
+	if !ok {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeProblem(w, r, http.StatusInternalServerError, "streaming_unsupported", "The connection does not support streaming responses.")
+		return
+	}
+
+	ctx := r.Context()
+	snapshot := func() (streamSnapshot, error) {
+		collections, err := s.collections(ctx, addr, false)
+		if err != nil {
+			return streamSnapshot{}, err
+		}
+		now := time.Now().In(s.location)
+		return streamSnapshot{
+			Date:     now.Format("2006-01-02"),
+			Today:    today(now, collections, s.location, s.todayWindow()),
+			Tomorrow: tomorrow(now, collections, s.location),
+		}, nil
+	}
+
+	current, err := snapshot()
+	if err != nil {
+		s.respondUnavailable(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := writeEvent(w, "types", current); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	interval := s.cfg.StreamInterval
+	if interval <= 0 {
+		interval = defaultStreamInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			next, err := snapshot()
+			if err != nil {
+				s.loggerFor(ctx).Warn("stream refresh failed", slog.String("error", err.Error()))
+				continue
+			}
+			if next.sameTypes(current) {
+				continue
+			}
+			current = next
+			if err := writeEvent(w, "types", current); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// failureWebhook POSTs a JSON alert once scraping an address has failed
+// threshold times in a row. It fires once per run of failures and is re-armed
+// by the address's next successful scrape, so a long outage sends one alert
+// rather than one per failure. Addresses are counted separately, so one
+// address recovering doesn't hide another's outage.
+type failureWebhook struct {
+	url       string
+	threshold int
+	client    *http.Client
+	logger    *slog.Logger
+
+	mu       sync.Mutex
+	failures map[string]int
+}
+
+// newFailureWebhook returns a webhook; an empty url disables it.
+func newFailureWebhook(url string, threshold int, timeout time.Duration, logger *slog.Logger) *failureWebhook {
+	if threshold <= 0 {
+		threshold = 1
+	}
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &failureWebhook{
+		url:       url,
+		threshold: threshold,
+		client:    &http.Client{Timeout: timeout},
+		logger:    logger,
+		failures:  make(map[string]int),
+	}
+}
+
+// record notes the outcome of a scrape of uprn, last scraped successfully at
+// lastSuccess. The failure that reaches the threshold starts a delivery in
+// the background, so it never delays the request that triggered the scrape.
+func (h *failureWebhook) record(uprn string, err error, lastSuccess time.Time) {
+	if h.url == "" {
+		return
+	}
+	h.mu.Lock()
+	if err == nil {
+		delete(h.failures, uprn)
+		h.mu.Unlock()
+		return
+	}
+	h.failures[uprn]++
+	failures := h.failures[uprn]
+	h.mu.Unlock()
+	if failures != h.threshold {
+		return
+	}
+
+	payload := map[string]interface{}{
+		"uprn":                 uprn,
+		"error":                err.Error(),
+		"consecutive_failures": failures,
+		"last_success":         nil,
+	}
+	if !lastSuccess.IsZero() {
+		payload["last_success"] = lastSuccess.UTC().Format(time.RFC3339)
+	}
+	go h.deliver(payload)
+}
+
+func (h *failureWebhook) deliver(payload map[string]interface{}) {
+	if err := h.post(payload); err != nil {
+		h.logger.Warn("failure webhook not delivered", slog.String("error", err.Error()))
+		return
+	}
+	h.logger.Info("failure webhook delivered",
+		slog.Any("uprn", payload["uprn"]),
+		slog.Any("consecutive_failures", payload["consecutive_failures"]),
+	)
+}
+
+func (h *failureWebhook) post(payload map[string]interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}